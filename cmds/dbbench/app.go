@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"db_optimization_techs/pkgs/dals"
+	"db_optimization_techs/pkgs/models"
+	"db_optimization_techs/pkgs/monitor"
+	"db_optimization_techs/pkgs/services"
+	"db_optimization_techs/pkgs/utils"
+
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// defaultConfigFile 未指定 -conf 时读取的配置文件
+const defaultConfigFile = "config.json"
+
+// app 子命令共用的运行环境：配置、日志与数据库连接，Close 时按相反顺序释放
+// 对多个目标执行时，每个目标由 connect 得到一个共用配置与日志、持有各自数据库连接的 app
+type app struct {
+	config     *models.Config
+	db         *gorm.DB
+	gormLogger *dals.GormLogger
+	tracer     trace.Tracer // 配置了链路追踪时非空，各目标共用同一个导出器
+	closers    []func()
+}
+
+// configFlags 各子命令共用的配置参数：配置文件与 -set 覆盖项
+// 配置优先级从高到低为 -set、DBBENCH_ 前缀的环境变量、配置文件、默认值
+type configFlags struct {
+	file      string
+	overrides stringList
+}
+
+// addConfigFlags 在 fs 上注册 -conf 与 -set 参数
+func addConfigFlags(fs *flag.FlagSet) *configFlags {
+	c := &configFlags{}
+	fs.StringVar(&c.file, "conf", defaultConfigFile, "配置文件路径，为空时只使用默认值、环境变量与 -set")
+	fs.Var(&c.overrides, "set", "覆盖配置项，形如 workload.concurrency=16，可重复指定")
+	return c
+}
+
+// stringList 可重复指定的字符串参数
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// loadConfig 读取并解析配置：文件中未出现的字段取默认值，再叠加环境变量与 -set 覆盖项，
+// 配置中没有密码时从密码文件、~/.my.cnf 或 ~/.pgpass 读取，最后校验全部字段
+func loadConfig(flags *configFlags) (*models.Config, error) {
+	if flags.file != "" {
+		if _, err := os.Stat(flags.file); os.IsNotExist(err) {
+			return nil, fmt.Errorf("配置文件不存在: %s，请先创建配置文件", flags.file)
+		}
+	}
+	config := models.DefaultConfig()
+	keys := utils.ConfigKeys(config)
+	if err := utils.InitViper(flags.file, keys); err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	if err := utils.SetOverrides(flags.overrides, keys); err != nil {
+		return nil, err
+	}
+	if err := viper.Unmarshal(config); err != nil {
+		return nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+	// 各目标以顶层 database 为基础，只需写出不同的字段
+	targets, err := utils.UnmarshalList("targets", models.TargetConfig{Database: config.Database})
+	if err != nil {
+		return nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+	config.Targets = targets
+	if err := utils.ResolvePassword(&config.Database); err != nil {
+		return nil, err
+	}
+	for i := range config.Targets {
+		if err := utils.ResolvePassword(&config.Targets[i].Database); err != nil {
+			return nil, fmt.Errorf("目标 %s: %w", config.Targets[i].Name, err)
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("配置 %s 校验失败:\n%w", flags.file, err)
+	}
+	return config, nil
+}
+
+// openApp 读取配置，初始化日志与顶层 database 的数据库连接
+func openApp(flags *configFlags, strategy string) (*app, error) {
+	a, err := newApp(flags, strategy)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := a.connect(models.TargetConfig{Database: a.config.Database}, strategy)
+	if err != nil {
+		a.Close()
+		return nil, err
+	}
+	a.db = conn.db
+	a.closers = append(a.closers, conn.Close)
+	return a, nil
+}
+
+// newApp 读取配置，初始化日志与链路追踪，不连接数据库；strategy 作为链路追踪的资源属性 dbbench.strategy
+func newApp(flags *configFlags, strategy string) (*app, error) {
+	config, err := loadConfig(flags)
+	if err != nil {
+		return nil, err
+	}
+	a := &app{config: config}
+
+	// 初始化日志：之后的日志按配置的级别、格式与输出位置写入
+	closeLog, err := utils.InitLogger(&config.Log)
+	if err != nil {
+		return nil, fmt.Errorf("初始化日志失败: %w", err)
+	}
+	a.closers = append(a.closers, func() { closeLog() })
+	a.gormLogger, err = dals.NewGormLogger(&config.Log)
+	if err != nil {
+		a.Close()
+		return nil, fmt.Errorf("初始化 GORM 日志失败: %w", err)
+	}
+
+	// 链路追踪：为每条 SQL 生成一个 span
+	if config.Monitor.TraceExporter != "" {
+		provider, shutdown, err := monitor.NewTracerProvider(monitor.Tracing{
+			Exporter:    config.Monitor.TraceExporter,
+			Endpoint:    config.Monitor.TraceEndpoint,
+			File:        config.Monitor.TraceFile,
+			SampleRatio: config.Monitor.TraceSampleRatio,
+		}, strategy)
+		if err != nil {
+			a.Close()
+			return nil, fmt.Errorf("初始化链路追踪失败: %w", err)
+		}
+		a.closers = append(a.closers, func() {
+			if err := shutdown(context.Background()); err != nil {
+				slog.Warn("关闭链路追踪失败", "error", err)
+			}
+		})
+		a.tracer = provider.Tracer("db_optimization_techs/pkgs/dals")
+	}
+	return a, nil
+}
+
+// connect 连接 target 的数据库，返回以 target.Database 为数据库配置的 app，Close 时关闭连接；
+// 配置了链路追踪时为 GORM 注册追踪插件，strategy 与目标名称作为 span 属性
+func (a *app) connect(target models.TargetConfig, strategy string) (*app, error) {
+	config := *a.config
+	config.Database = target.Database
+	conn := &app{config: &config, gormLogger: a.gormLogger, tracer: a.tracer}
+
+	db, err := dals.InitDB(&config.Database, a.gormLogger)
+	if err != nil {
+		return nil, fmt.Errorf("初始化数据库失败: %w", err)
+	}
+	conn.db = db
+	conn.closers = append(conn.closers, func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	slog.Info("数据库连接成功", "target", target.Name, "database", config.Database)
+
+	if a.tracer != nil {
+		if err := db.Use(dals.NewTracingPlugin(a.tracer, strategy, target.Name)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("注册链路追踪插件失败: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// Close 按注册的相反顺序释放资源
+func (a *app) Close() {
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		a.closers[i]()
+	}
+	a.closers = nil
+}
+
+// observe 按监控配置为 service 注册阶段观察者
+func (a *app) observe(service services.Benchmark, strategy string) error {
+	m := &a.config.Monitor
+
+	// 附加观测：每个阶段前后采集服务端计数器差值与语句摘要，运行期间采样主机资源
+	if m.ServerStatus && a.mysqlOnly("monitor.server_status") {
+		service.AddObserver(monitor.NewServerStatusObserver(a.db))
+	}
+	if m.StatementDigests && a.mysqlOnly("monitor.statement_digests") {
+		service.AddObserver(monitor.NewDigestObserver(a.db, m.DigestLimit))
+	}
+	if m.Host {
+		service.AddObserver(monitor.NewHostObserver(m.HostInterval, m.HostDevices))
+	}
+	service.SetSlowestOps(m.SlowestOps)
+	if a.config.Log.SlowQueryMode == dals.SlowQueryCount {
+		service.AddObserver(monitor.NewSlowQueryObserver(a.gormLogger))
+	}
+	if m.Progress {
+		service.AddObserver(monitor.NewProgress(m.ProgressInterval))
+	}
+	if m.MetricsAddr != "" {
+		sqlDB, err := a.db.DB()
+		if err != nil {
+			return fmt.Errorf("获取数据库连接池失败: %w", err)
+		}
+		database := a.config.Database.Database
+		if a.config.Database.Type == models.DatabaseSQLite {
+			database = a.config.Database.File
+		}
+		metrics := monitor.NewMetrics(strategy, sqlDB, database)
+		service.AddObserver(metrics)
+		server := metrics.Serve(m.MetricsAddr)
+		a.closers = append(a.closers, func() { server.Close() })
+		slog.Info("Prometheus 指标已启动", "url", "http://"+m.MetricsAddr+"/metrics")
+	}
+	return nil
+}
+
+// mysqlOnly 判断连接的数据库能否使用只有 MySQL 才有的功能（SHOW STATUS、performance_schema、EXPLAIN FORMAT=JSON、information_schema），
+// 不能时记录一条告警，调用方跳过该功能；feature 为对应的配置项
+func (a *app) mysqlOnly(feature string) bool {
+	if a.config.Database.Type != models.DatabaseSQLite {
+		return true
+	}
+	slog.Warn("SQLite 不支持该功能，已跳过", "feature", feature)
+	return false
+}
+
+// interruptContext 返回收到 SIGINT 或 SIGTERM 时结束的上下文：各阶段停止发起新的操作，等待进行中的操作完成后写入部分结果
+// 收到信号后恢复默认的信号处理，再次中断会立即退出进程
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			signal.Stop(signals)
+			slog.Warn("收到中断信号，等待进行中的操作完成后写入部分结果，再次中断将立即退出", "signal", sig.String())
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
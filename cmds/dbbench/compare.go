@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"db_optimization_techs/pkgs/models"
+)
+
+// compareCommand 读取多个 run 输出的结果文件，按阶段并列打印各结果的吞吐与 p99 延迟，
+// 并给出相对第一个结果的吞吐比值，用于对比不同主键策略、数据量级或压测目标；
+// 多目标运行的结果文件中每个目标各占一列，因此只给一个这样的文件也可以对比
+// 用法: dbbench compare results/uuid.json results/crc32_uuid.json
+func compareCommand(args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: dbbench compare <结果文件> [结果文件...]")
+	}
+	fs.Parse(args)
+
+	var results []*models.RunResult
+	for _, file := range fs.Args() {
+		runs, err := readRunResults(file)
+		if err != nil {
+			return err
+		}
+		results = append(results, runs...)
+	}
+	if len(results) < 2 {
+		fs.Usage()
+		return fmt.Errorf("至少需要两个结果")
+	}
+
+	// 阶段按第一次出现的顺序排列，某个文件缺少该阶段时显示为 -
+	var phases []string
+	seen := make(map[string]bool)
+	for _, result := range results {
+		for _, phase := range result.Phases {
+			if !seen[phase.Phase] {
+				seen[phase.Phase] = true
+				phases = append(phases, phase.Phase)
+			}
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(tw, "阶段")
+	for i, result := range results {
+		fmt.Fprintf(tw, "\t%s ops/s\t%s p99(ms)", label(i, result), label(i, result))
+		if i > 0 {
+			fmt.Fprintf(tw, "\t%s 吞吐比", label(i, result))
+		}
+	}
+	fmt.Fprintln(tw)
+
+	incomplete := false
+	for _, name := range phases {
+		fmt.Fprint(tw, name)
+		base := findPhase(results[0], name)
+		for i, result := range results {
+			phase := findPhase(result, name)
+			if phase == nil {
+				fmt.Fprint(tw, "\t-\t-")
+				if i > 0 {
+					fmt.Fprint(tw, "\t-")
+				}
+				continue
+			}
+			// 未完成的阶段以 * 标记，吞吐按已完成的操作计算
+			mark := ""
+			if phase.Incomplete {
+				mark = "*"
+				incomplete = true
+			}
+			fmt.Fprintf(tw, "\t%.1f%s\t%.2f", phase.OpsPerSec, mark, phase.Latency.P99Ms)
+			if i > 0 {
+				if base != nil && base.OpsPerSec > 0 {
+					fmt.Fprintf(tw, "\t%.2fx", phase.OpsPerSec/base.OpsPerSec)
+				} else {
+					fmt.Fprint(tw, "\t-")
+				}
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if incomplete {
+		fmt.Println("\n* 阶段未完成（中断或超时），吞吐按已完成的操作计算")
+	}
+	return nil
+}
+
+// readRunResults 读取 run 子命令写入的结果文件，多目标运行的文件返回其中每个目标的结果
+func readRunResults(file string) ([]*models.RunResult, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("读取结果文件失败: %w", err)
+	}
+	var set models.RunSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("解析结果文件 %s 失败: %w", file, err)
+	}
+	if set.Runs != nil {
+		return set.Runs, nil
+	}
+	var result models.RunResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("解析结果文件 %s 失败: %w", file, err)
+	}
+	return []*models.RunResult{&result}, nil
+}
+
+// findPhase 返回结果中第一个名为 name 的阶段，不存在时返回 nil
+func findPhase(result *models.RunResult, name string) *models.PhaseResult {
+	for _, phase := range result.Phases {
+		if phase.Phase == name {
+			return phase
+		}
+	}
+	return nil
+}
+
+// label 返回表头中代表第 i 个结果的名称，优先使用压测目标，其次为主键策略
+func label(i int, result *models.RunResult) string {
+	switch {
+	case result.Target != "":
+		return fmt.Sprintf("[%d]%s", i+1, result.Target)
+	case result.Strategy != "":
+		return fmt.Sprintf("[%d]%s", i+1, result.Strategy)
+	default:
+		return fmt.Sprintf("[%d]", i+1)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"db_optimization_techs/pkgs/models"
+	"db_optimization_techs/pkgs/utils"
+)
+
+// configCommand 生成带全部默认值的示例配置，在不连接数据库的情况下校验配置，打印生效的配置，或列出可用的环境变量
+// 用法:
+//
+//	dbbench config example [-o configs/example.json]
+//	dbbench config validate -conf configs/case1.json
+//	dbbench config show -conf configs/case1.json -set workload.concurrency=16
+//	dbbench config env
+func configCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("缺少操作，可选 example、validate、show、env")
+	}
+	switch args[0] {
+	case "example":
+		fs := flag.NewFlagSet("config example", flag.ExitOnError)
+		output := fs.String("o", "", "输出文件路径，为空时打印到标准输出")
+		fs.Parse(args[1:])
+
+		data, err := utils.MarshalConfig(models.DefaultConfig())
+		if err != nil {
+			return fmt.Errorf("序列化示例配置失败: %w", err)
+		}
+		if *output == "" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		return os.WriteFile(*output, data, 0o644)
+	case "validate":
+		fs := flag.NewFlagSet("config validate", flag.ExitOnError)
+		configFlags := addConfigFlags(fs)
+		fs.Parse(args[1:])
+
+		// 逐行打印全部问题，便于一次改完
+		if _, err := loadConfig(configFlags); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s: 配置有效\n", configFlags.file)
+		return nil
+	case "show":
+		fs := flag.NewFlagSet("config show", flag.ExitOnError)
+		configFlags := addConfigFlags(fs)
+		fs.Parse(args[1:])
+
+		// 叠加默认值、环境变量与 -set 之后实际生效的配置，密码以 ****** 代替
+		config, err := loadConfig(configFlags)
+		if err != nil {
+			return err
+		}
+		data, err := utils.MarshalConfig(config.Redacted())
+		if err != nil {
+			return fmt.Errorf("序列化配置失败: %w", err)
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	case "env":
+		// 列出每个配置项对应的环境变量名
+		for _, key := range utils.ConfigKeys(models.DefaultConfig()) {
+			fmt.Printf("%-50s %s\n", utils.EnvName(key), key)
+		}
+		return nil
+	default:
+		return fmt.Errorf("未知的操作 %q，可选 example、validate、show、env", args[0])
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"db_optimization_techs/pkgs/dals"
+	"db_optimization_techs/pkgs/models"
+
+	"github.com/google/uuid"
+)
+
+// dryRun 打印 run 将要执行的内容：解析后的目标与种子、建表语句、各阶段的参数与每种 DAL 操作的示例 SQL
+// 示例 SQL 在不连接数据库的 DryRun 会话中按各目标的 SQL 方言生成，整个过程不访问数据库
+func (r *scenarioRun) dryRun(out io.Writer, config *models.Config) error {
+	sc := r.scenario
+	w := &config.Workload
+
+	fmt.Fprintf(out, "场景: %s", sc.Name)
+	if sc.Description != "" {
+		fmt.Fprintf(out, " - %s", sc.Description)
+	}
+	fmt.Fprintf(out, "\n主键策略: %s\n压测表: %s\n", r.strategyName, r.table)
+	if w.Seed == 0 {
+		fmt.Fprintln(out, "种子: 运行时随机选取（workload.seed 为 0）")
+	} else {
+		fmt.Fprintf(out, "种子: %d（固定种子每次运行生成相同的主键，压测表中已有本种子此前写入的数据时写入阶段会因主键重复而失败，重复运行前请清空表）\n", w.Seed)
+	}
+	if w.OpTimeout > 0 {
+		fmt.Fprintf(out, "单次操作超时: %s\n", w.OpTimeout)
+	}
+	fmt.Fprintln(out, "目标:")
+	// dbTypes 各目标的数据库类型（去重、保持顺序），建表语句与示例 SQL 按类型分别打印
+	var dbTypes []string
+	for _, target := range config.RunTargets() {
+		db := target.Database
+		name := target.Name
+		if name == "" {
+			name = "(database)"
+		}
+		if db.Type == models.DatabaseSQLite {
+			fmt.Fprintf(out, "  %s: sqlite %s\n", name, db.File)
+		} else {
+			fmt.Fprintf(out, "  %s: %s@%s:%d/%s\n", name, db.User, db.Host, db.Port, db.Database)
+		}
+		if !slices.Contains(dbTypes, db.Type) {
+			dbTypes = append(dbTypes, db.Type)
+		}
+	}
+	// dialectSuffix 目标中有多种数据库类型时，在标题后注明类型
+	dialectSuffix := func(dbType string) string {
+		if len(dbTypes) == 1 {
+			return ""
+		}
+		return fmt.Sprintf("（%s）", dbType)
+	}
+
+	fmt.Fprintln(out, "\n建表语句:")
+	if sc.DDL != "" {
+		for _, dbType := range dbTypes {
+			fmt.Fprintf(out, "-- 表 %s 不存在时执行%s\n%s\n", r.table, dialectSuffix(dbType), strings.TrimSpace(sc.DDLFor(dbType)))
+		}
+	} else {
+		fmt.Fprintf(out, "-- 场景没有建表语句，run 不会建表；表 %s 需已存在（可用 schema -apply 创建）\n", r.table)
+	}
+
+	fmt.Fprintln(out, "\n执行步骤:")
+	step := 0
+	printStep := func(format string, args ...any) {
+		step++
+		fmt.Fprintf(out, "  %d. %s\n", step, fmt.Sprintf(format, args...))
+	}
+	if p := sc.Preload; p != nil {
+		batchSize, concurrency := p.BatchSize, p.Concurrency
+		if batchSize == 0 {
+			batchSize = 1000
+		}
+		if concurrency == 0 {
+			concurrency = 16
+		}
+		printStep("preload: 补齐到 %d 行（tier=%s），batch_size=%d concurrency=%d", p.Rows, p.Tier, batchSize, concurrency)
+	}
+	for _, spec := range sc.Phases {
+		pw := spec.Apply(*w)
+		name := phaseName(spec)
+		if name != spec.Op {
+			name = fmt.Sprintf("%s (%s)", name, spec.Op)
+		}
+		printStep("%s: %s", name, phaseParams(spec.Op, &pw))
+		if check := verifyPlan(spec.Op, &pw); check != "" {
+			fmt.Fprintf(out, "     之后校验: %s\n", check)
+		}
+	}
+	if w.SweepPhase != "" {
+		printStep("并发度扫描 %s: %s concurrency=%v", w.SweepPhase, phaseParams(w.SweepPhase, w), w.SweepConcurrency)
+	}
+	if len(w.BatchSizes) > 0 {
+		printStep("批大小扫描: rows=%d batch_sizes=%v concurrency=%d", w.BatchTotalRows, w.BatchSizes, w.Concurrency)
+	}
+	if w.SoakOp != "" {
+		printStep("长时间运行 soak_%s: %s", w.SoakOp, phaseParams("soak_"+w.SoakOp, w))
+	}
+	skipSQLite := ""
+	if slices.Contains(dbTypes, models.DatabaseSQLite) {
+		skipSQLite = "，SQLite 目标跳过"
+	}
+	if config.Monitor.Explain {
+		printStep("执行计划: 对下列 SQL 执行 EXPLAIN（analyze=%t）%s", config.Monitor.ExplainAnalyze, skipSQLite)
+	}
+	if config.Monitor.TableSize {
+		printStep("存储占用: 统计表 %s 的数据与索引大小%s", r.table, skipSQLite)
+	}
+
+	// 示例 SQL：以随机主键调用每种 DAL 操作，实际运行时主键由种子生成
+	sampleUUID := uuid.New().String()
+	for _, dbType := range dbTypes {
+		dry, err := dals.OpenDryRun(dbType, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "\n示例 SQL%s:\n", dialectSuffix(dbType))
+		for _, shape := range r.strategy.queryShapes(r.table, sampleUUID) {
+			statements, err := dals.CaptureSQL(dry, shape.Call)
+			if err != nil {
+				return fmt.Errorf("获取 %s 的 SQL 失败: %w", shape.Name, err)
+			}
+			fmt.Fprintf(out, "  %s:\n", shape.Name)
+			for _, statement := range statements {
+				fmt.Fprintf(out, "    %s;\n", dry.Dialector.Explain(statement.SQL, statement.Vars...))
+			}
+		}
+	}
+	return nil
+}
+
+// verifyPlan 返回开启数据校验时阶段 op 之后执行的校验，未开启或阶段没有可校验的写入时返回空字符串
+func verifyPlan(op string, w *models.WorkloadConfig) string {
+	if !w.Verify {
+		return ""
+	}
+	sample := fmt.Sprintf("成功写入的主键均存在，并回读抽样 %d 行比对写入的值", w.VerifySample)
+	switch op {
+	case "create", "insert_batch", "upsert":
+		return sample
+	case "update":
+		if w.Distribution == "uniform" || w.Distribution == "zipf" {
+			return "更新过的主键均存在（有放回的分布下最终值取决于执行顺序，不比对值）"
+		}
+		return sample
+	case "tx_rmw":
+		return "成功事务访问的主键均存在"
+	case "delete":
+		return "成功删除的主键均已不存在"
+	default:
+		return ""
+	}
+}
+
+// phaseParams 返回阶段 op 在负载参数 w 下实际使用的参数，用于 dry-run 输出
+func phaseParams(op string, w *models.WorkloadConfig) string {
+	var params string
+	switch op {
+	case "get", "update":
+		params = fmt.Sprintf("ops=%d", w.PhaseOps)
+		if w.Distribution != "" {
+			params += " distribution=" + w.Distribution
+		}
+	case "upsert":
+		params = fmt.Sprintf("ops=%d conflict_rate=%g", w.PhaseOps, w.UpsertConflictRate)
+	case "tx_rmw":
+		isolation := w.TxIsolation
+		if isolation == "" {
+			isolation = "数据库默认"
+		}
+		// 准备 phase_ops 条记录，每个事务访问 keys_per_tx 个主键，共执行 phase_ops/keys_per_tx 个事务
+		txs := 0
+		if w.TxKeysPerTx > 0 {
+			txs = w.PhaseOps / w.TxKeysPerTx
+		}
+		params = fmt.Sprintf("txs=%d rows=%d keys_per_tx=%d isolation=%s max_retries=%d missing_key_rate=%g",
+			txs, w.PhaseOps, w.TxKeysPerTx, isolation, w.TxMaxRetries, w.TxMissingKeyRate)
+	case "insert_batch":
+		params = fmt.Sprintf("rows=%d batch_size=%d", w.BatchTotalRows, w.BatchSize)
+	case "soak_create", "soak_mixed":
+		params = fmt.Sprintf("duration=%s interval=%s", w.SoakDuration, w.SoakInterval)
+	default:
+		params = fmt.Sprintf("ops=%d", w.PhaseOps)
+	}
+	params += fmt.Sprintf(" concurrency=%d", w.Concurrency)
+	if w.PhaseTimeout > 0 {
+		params += fmt.Sprintf(" timeout=%s", w.PhaseTimeout)
+	}
+	return params
+}
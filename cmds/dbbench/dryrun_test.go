@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"db_optimization_techs/pkgs/models"
+)
+
+func TestPhaseParamsTxRMW(t *testing.T) {
+	w := models.DefaultConfig().Workload
+	w.PhaseOps = 10000
+	w.TxKeysPerTx = 4
+	got := phaseParams("tx_rmw", &w)
+	if !strings.HasPrefix(got, "txs=2500 rows=10000 keys_per_tx=4 ") {
+		t.Fatalf("tx_rmw 应执行 phase_ops/keys_per_tx 个事务，实际输出 %q", got)
+	}
+
+	w.TxKeysPerTx = 0
+	if got := phaseParams("tx_rmw", &w); !strings.HasPrefix(got, "txs=0 ") {
+		t.Fatalf("keys_per_tx 为 0 时输出 %q", got)
+	}
+}
+
+func TestDryRunTxRMWLine(t *testing.T) {
+	st, err := lookupStrategy("uuid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := models.DefaultConfig()
+	config.Workload.PhaseOps = 10000
+	config.Workload.TxKeysPerTx = 4
+	config.Monitor.Explain = false
+	config.Monitor.TableSize = false
+	run := &scenarioRun{
+		scenario: &models.Scenario{Name: "tx", Phases: []models.PhaseSpec{{Op: "tx_rmw"}}},
+		strategy: st, strategyName: "uuid", table: st.table,
+	}
+
+	var out bytes.Buffer
+	if err := run.dryRun(&out, config); err != nil {
+		t.Fatalf("dryRun: %v", err)
+	}
+	if !strings.Contains(out.String(), "1. tx_rmw: txs=2500 rows=10000 keys_per_tx=4 ") {
+		t.Fatalf("dry-run 输出中没有正确的事务数:\n%s", out.String())
+	}
+}
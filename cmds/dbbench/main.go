@@ -0,0 +1,70 @@
+// dbbench 数据库主键策略压测工具
+//
+// 用法:
+//
+//	dbbench run     -conf configs/case1.json -scenario crud -strategy uuid
+//	dbbench preload -conf configs/case1.json -strategy crc32_uuid -rows 100000000
+//	dbbench report  -conf configs/case1.json -tier 100m -o results/size.json
+//	dbbench compare results/uuid.json results/crc32_uuid.json
+//	dbbench schema  -conf configs/case1.json -apply
+//	dbbench config  example -o configs/example.json
+//	dbbench config  validate -conf configs/case1.json
+//	dbbench config  show -conf configs/case1.json -set workload.concurrency=16
+//	dbbench config  env
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"db_optimization_techs/pkgs/utils"
+)
+
+// command 子命令
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"run", "按场景执行压测阶段并写入结果文件", runCommand},
+	{"preload", "以批量插入把压测表预填充到指定行数", preloadCommand},
+	{"report", "报告压测表的行数估算与存储占用", reportCommand},
+	{"compare", "对比多个结果文件中各阶段的吞吐与延迟", compareCommand},
+	{"schema", "打印或执行压测表的建表语句", schemaCommand},
+	{"config", "生成带默认值的示例配置（example）、校验配置文件（validate）、打印生效的配置（show）或列出环境变量（env）", configCommand},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				utils.Fatal(name+" 失败", "error", err)
+			}
+			return
+		}
+	}
+	if name != "-h" && name != "-help" && name != "help" {
+		fmt.Fprintf(os.Stderr, "未知的子命令: %s\n\n", name)
+	}
+	printUsage()
+	os.Exit(2)
+}
+
+// printUsage 打印子命令列表
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "用法: dbbench <子命令> [参数]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "子命令:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "使用 dbbench <子命令> -h 查看各子命令的参数")
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"db_optimization_techs/pkgs/models"
+	"db_optimization_techs/pkgs/utils"
+)
+
+// preloadCommand 以多行 INSERT 向压测表批量写入随机主键的记录，用于准备百万、亿级等数据量级
+// 只插入指定的行数，不检查表中已有的数据量；配置了 monitor.progress 时可实时查看进度与剩余时间；
+// 收到 SIGINT/SIGTERM 时等待进行中的批次完成，写入标记为 incomplete 的部分结果
+func preloadCommand(args []string) error {
+	fs := flag.NewFlagSet("preload", flag.ExitOnError)
+	configFlags := addConfigFlags(fs)
+	strategyName := fs.String("strategy", "uuid", "主键策略: uuid、crc32_uuid")
+	rows := fs.Int("rows", 1000000, "插入的总行数")
+	batchSize := fs.Int("batch-size", 1000, "每条 INSERT 的行数")
+	concurrency := fs.Int("concurrency", 16, "并发 worker 数")
+	output := fs.String("o", "", "结果 JSON 文件路径，为空时只打印日志")
+	fs.Parse(args)
+
+	st, err := lookupStrategy(*strategyName)
+	if err != nil {
+		return err
+	}
+	a, err := openApp(configFlags, *strategyName)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	service := st.newService(a.db, st.table)
+	service.SetOpTimeout(a.config.Workload.OpTimeout)
+	if err := a.observe(service, *strategyName); err != nil {
+		return err
+	}
+
+	slog.Info("开始预填充", "table", st.table, "rows", *rows, "batch_size", *batchSize, "concurrency", *concurrency)
+	ctx, stop := interruptContext()
+	defer stop()
+	startedAt := time.Now()
+	phaseResult, err := service.InsertBatch(ctx, *rows, *batchSize, *concurrency)
+	if err != nil && (phaseResult == nil || !phaseResult.Incomplete) {
+		return fmt.Errorf("预填充失败: %w", err)
+	}
+	slog.Info("预填充完成", "result", phaseResult)
+
+	if *output != "" {
+		result := &models.RunResult{Strategy: *strategyName, StartedAt: startedAt, Phases: []*models.PhaseResult{phaseResult},
+			Incomplete: phaseResult.Incomplete}
+		if err := utils.WriteJSONFile(*output, result); err != nil {
+			return fmt.Errorf("写入结果文件失败: %w", err)
+		}
+		slog.Info("结果已写入", "file", *output)
+	}
+	if phaseResult.Incomplete {
+		return fmt.Errorf("预填充被中断: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"db_optimization_techs/pkgs/dals"
+	"db_optimization_techs/pkgs/models"
+	"db_optimization_techs/pkgs/services"
+	"db_optimization_techs/pkgs/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// runCommand 对每个目标按场景依次执行压测阶段，再按配置执行并发度扫描、批大小扫描、长时间运行、执行计划与存储占用统计
+// 场景可以是内置场景名或 YAML/JSON 场景文件；配置了多个目标时依次执行，各目标使用相同的种子与主键序列，结果合并写入同一个文件；
+// 收到 SIGINT/SIGTERM 时等待进行中的操作完成，写入标记为 incomplete 的部分结果
+func runCommand(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	configFlags := addConfigFlags(fs)
+	scenarioName := fs.String("scenario", "crud", "压测场景: crud、batch_insert，或 YAML/JSON 场景文件路径")
+	strategyName := fs.String("strategy", "", "主键策略: uuid、crc32_uuid，为空时取场景中的策略，场景未指定时为 uuid")
+	phases := fs.String("phases", "", "逗号分隔的阶段列表，覆盖场景的阶段，如 create,get")
+	output := fs.String("o", "", "结果 JSON 文件路径，覆盖配置中的 output.result_file")
+	dryRun := fs.Bool("dry-run", false, "只打印解析后的目标、建表语句、执行步骤与各 DAL 操作的示例 SQL，不访问数据库")
+	fs.Parse(args)
+
+	sc, err := lookupScenario(*scenarioName)
+	if err != nil {
+		return err
+	}
+	if *phases != "" {
+		sc.Phases = opPhases(splitList(*phases)...)
+		if err := sc.Validate(); err != nil {
+			return fmt.Errorf("-phases 参数无效:\n%w", err)
+		}
+	}
+	if *strategyName == "" {
+		*strategyName = sc.Strategy
+	}
+	if *strategyName == "" {
+		*strategyName = "uuid"
+	}
+	st, err := lookupStrategy(*strategyName)
+	if err != nil {
+		return err
+	}
+	// 压测表：场景指定的表，其次是场景建表语句中的表，最后是主键策略的默认表
+	table := sc.Table
+	if table == "" {
+		table = sc.DDLTable()
+	}
+	if table == "" {
+		table = st.table
+	}
+	run := &scenarioRun{scenario: sc, strategy: st, strategyName: *strategyName, table: table}
+
+	if *dryRun {
+		config, err := loadConfig(configFlags)
+		if err != nil {
+			return err
+		}
+		return run.dryRun(os.Stdout, config)
+	}
+
+	a, err := newApp(configFlags, *strategyName)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	config := a.config
+	ctx, stop := interruptContext()
+	defer stop()
+	if *output != "" {
+		config.Output.ResultFile = *output
+	}
+
+	// 种子为 0 时随机选取，记录在结果中以便复现
+	seed := config.Workload.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	targets := config.RunTargets()
+	targetNames := make([]string, 0, len(targets))
+	for _, target := range targets {
+		targetNames = append(targetNames, target.Name)
+	}
+	phaseNames := make([]string, 0, len(sc.Phases))
+	for _, spec := range sc.Phases {
+		phaseNames = append(phaseNames, phaseName(spec))
+	}
+	slog.Info("开始性能测试", "scenario", sc.Name, "strategy", *strategyName, "table", table, "phases", phaseNames,
+		"targets", targetNames, "seed", seed)
+
+	run.seed = seed
+	set := &models.RunSet{Scenario: sc.Name, Strategy: *strategyName, Seed: seed, StartedAt: time.Now()}
+	// 某个目标失败时记录其已完成的部分并继续执行后续目标，最后照常写入结果文件
+	var failures []error
+	for _, target := range targets {
+		result, err := run.target(ctx, a, target)
+		set.Runs = append(set.Runs, result)
+		if result.Incomplete {
+			set.Incomplete = true
+		}
+		if err != nil {
+			if target.Name != "" {
+				err = fmt.Errorf("目标 %s: %w", target.Name, err)
+			}
+			slog.Error("目标运行失败，结果中只包含失败之前完成的部分", "target", target.Name, "error", err)
+			failures = append(failures, err)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	// 未配置 targets 时结果文件与单目标运行的格式相同
+	if config.Output.ResultFile != "" {
+		var content any = set
+		if len(config.Targets) == 0 {
+			content = set.Runs[0]
+		}
+		if err := utils.WriteJSONFile(config.Output.ResultFile, content); err != nil {
+			return fmt.Errorf("写入结果文件失败: %w", err)
+		}
+		slog.Info("结果已写入", "file", config.Output.ResultFile)
+	}
+
+	if len(failures) > 0 {
+		return errors.Join(failures...)
+	}
+	if set.Incomplete {
+		return errors.New("压测被中断，结果中只包含中断前完成的部分")
+	}
+	if failed := failedVerifications(set); len(failed) > 0 {
+		return fmt.Errorf("数据校验未通过的阶段: %s，吞吐数据不可信", strings.Join(failed, ", "))
+	}
+	slog.Info("性能测试完成")
+	return nil
+}
+
+// scenarioRun 对各目标执行同一场景所需的参数
+type scenarioRun struct {
+	scenario     *models.Scenario
+	strategy     strategy
+	strategyName string
+	table        string
+	seed         uint64
+}
+
+// target 连接 target 的数据库并执行一轮完整的压测，返回该目标的结果
+// ctx 结束时跳过后续阶段，返回标记为 Incomplete 的部分结果；失败时同样返回已完成的部分，标记为 Incomplete 并记录错误
+func (r *scenarioRun) target(ctx context.Context, a *app, target models.TargetConfig) (result *models.RunResult, err error) {
+	result = &models.RunResult{Target: target.Name, Scenario: r.scenario.Name, Strategy: r.strategyName, Seed: r.seed, StartedAt: time.Now()}
+	defer func() {
+		if err != nil {
+			result.Incomplete = true
+			result.Error = err.Error()
+		}
+	}()
+
+	conn, err := a.connect(target, r.strategyName)
+	if err != nil {
+		return result, err
+	}
+	defer conn.Close()
+	config := conn.config
+	sc := r.scenario
+
+	if target.Name != "" {
+		slog.Info("开始压测目标", "target", target.Name)
+	}
+	// 场景自带建表语句时，表不存在则先建表
+	if ddl := sc.DDLFor(config.Database.Type); ddl != "" {
+		created, err := dals.ExecDDL(conn.db.WithContext(ctx), r.table, ddl)
+		if err != nil {
+			return result, err
+		}
+		if created {
+			slog.Info("表已创建", "table", r.table)
+		}
+	}
+
+	service := r.strategy.newService(conn.db, r.table)
+	service.SetSeed(r.seed)
+	service.SetOpTimeout(config.Workload.OpTimeout)
+	if err := conn.observe(service, r.strategyName); err != nil {
+		return result, err
+	}
+
+	w := &config.Workload
+	tier := config.Monitor.Tier
+	// interrupted 在收到中断信号后把结果标记为不完整，调用方随即返回已有的结果
+	interrupted := func() bool {
+		if ctx.Err() == nil {
+			return false
+		}
+		result.Incomplete = true
+		slog.Warn("运行已中断，跳过后续阶段", "target", target.Name)
+		return true
+	}
+
+	// 预填充：把表补齐到场景声明的数据量级，已达到时跳过；预填充不受阶段超时限制
+	if sc.Preload != nil {
+		phaseResult, err := preloadTable(ctx, service, sc.Preload)
+		if err != nil {
+			return result, err
+		}
+		if phaseResult != nil {
+			result.Phases = append(result.Phases, phaseResult)
+		}
+		if sc.Preload.Tier != "" {
+			tier = sc.Preload.Tier
+		}
+		if interrupted() {
+			return result, nil
+		}
+	}
+
+	for _, spec := range sc.Phases {
+		pw := spec.Apply(*w)
+		phaseResult, err := runStep(ctx, phaseName(spec), pw.PhaseTimeout, func(ctx context.Context) (*models.PhaseResult, error) {
+			if op := spec.SoakOp(); op != "" {
+				return soak(ctx, service, op, &pw, config.Output.TimeSeriesFile, target.Name)
+			}
+			phase, err := service.Phase(spec.Op, &pw)
+			if err != nil {
+				return nil, err
+			}
+			return phase(ctx, pw.Concurrency)
+		})
+		if err != nil {
+			// 有失败操作的阶段仍返回统计结果，一并写入以便排查
+			if phaseResult != nil {
+				if spec.Name != "" {
+					phaseResult.Phase = spec.Name
+				}
+				result.Phases = append(result.Phases, phaseResult)
+			}
+			return result, fmt.Errorf("阶段 %s 失败: %w", phaseName(spec), err)
+		}
+		if spec.Name != "" {
+			phaseResult.Phase = spec.Name
+		}
+		slog.Info("阶段完成", "result", phaseResult)
+		result.Phases = append(result.Phases, phaseResult)
+		if interrupted() {
+			return result, nil
+		}
+		// 数据校验：确认阶段成功的写入确实落表；准备数据时即超时的阶段没有执行任何操作，不校验
+		if pw.Verify && phaseResult.Ops > 0 {
+			verification, err := service.Verify(ctx, pw.VerifySample)
+			if err != nil {
+				return result, fmt.Errorf("校验阶段 %s 失败: %w", phaseName(spec), err)
+			}
+			if verification != nil {
+				phaseResult.Verification = verification
+				if verification.Passed {
+					slog.Info("阶段校验通过", "phase", phaseResult.Phase, "rows", verification.Rows, "sampled", verification.Sampled)
+				} else {
+					slog.Error("阶段校验未通过", "phase", phaseResult.Phase, "verification", verification.String(), "examples", verification.Examples)
+				}
+			}
+		}
+	}
+
+	// 并发度扫描：同一阶段在不同并发度下重复执行，找出吞吐饱和的拐点；每个并发度分别受阶段超时限制
+	if w.SweepPhase != "" {
+		phase, err := service.Phase(w.SweepPhase, w)
+		if err != nil {
+			return result, fmt.Errorf("获取扫描阶段失败: %w", err)
+		}
+		timed := func(ctx context.Context, concurrency int) (*models.PhaseResult, error) {
+			return runStep(ctx, w.SweepPhase, w.PhaseTimeout, func(ctx context.Context) (*models.PhaseResult, error) {
+				return phase(ctx, concurrency)
+			})
+		}
+		sweep, err := services.Sweep(ctx, w.SweepPhase, w.SweepConcurrency, timed)
+		if err != nil && ctx.Err() == nil {
+			return result, fmt.Errorf("并发度扫描失败: %w", err)
+		}
+		for _, point := range sweep.Points {
+			slog.Info("阶段完成", "result", point)
+		}
+		slog.Info("并发度扫描完成", "phase", sweep.Phase, "knee_concurrency", sweep.KneeConcurrency)
+		result.Sweeps = append(result.Sweeps, sweep)
+		if interrupted() {
+			return result, nil
+		}
+	}
+
+	// 批大小扫描：总行数不变，比较不同批大小下的行吞吐与单条语句延迟；每个批大小分别受阶段超时限制
+	if len(w.BatchSizes) > 0 {
+		timed := func(ctx context.Context, totalRows, batchSize, concurrency int) (*models.PhaseResult, error) {
+			return runStep(ctx, "insert_batch", w.PhaseTimeout, func(ctx context.Context) (*models.PhaseResult, error) {
+				return service.InsertBatch(ctx, totalRows, batchSize, concurrency)
+			})
+		}
+		batchSweep, err := services.SweepBatchSizes(ctx, w.BatchTotalRows, w.BatchSizes, w.Concurrency, timed)
+		if err != nil && ctx.Err() == nil {
+			return result, fmt.Errorf("批大小扫描失败: %w", err)
+		}
+		for _, point := range batchSweep.Points {
+			slog.Info("阶段完成", "result", point)
+		}
+		result.BatchSweeps = append(result.BatchSweeps, batchSweep)
+		if interrupted() {
+			return result, nil
+		}
+	}
+
+	// 长时间运行模式：持续施压，按间隔记录吞吐、延迟分位数与表行数的时间序列
+	if w.SoakOp != "" {
+		soakResult, err := runStep(ctx, "soak_"+w.SoakOp, w.PhaseTimeout, func(ctx context.Context) (*models.PhaseResult, error) {
+			return soak(ctx, service, w.SoakOp, w, config.Output.TimeSeriesFile, target.Name)
+		})
+		if err != nil {
+			return result, fmt.Errorf("长时间运行失败: %w", err)
+		}
+		slog.Info("阶段完成", "result", soakResult)
+		result.Phases = append(result.Phases, soakResult)
+		if interrupted() {
+			return result, nil
+		}
+	}
+
+	// 执行计划：以表中已有的主键为参数，对 DAL 的每种 SQL 执行 EXPLAIN，未按主键访问时告警
+	if config.Monitor.Explain && conn.mysqlOnly("monitor.explain") {
+		sampleUUID, err := r.strategy.sampleUUID(ctx, conn.db, r.table)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			sampleUUID = uuid.New().String()
+		} else if err != nil {
+			return result, fmt.Errorf("获取示例主键失败: %w", err)
+		}
+		plans, err := dals.ExplainQueryShapes(conn.db.WithContext(ctx), r.strategy.queryShapes(r.table, sampleUUID), config.Monitor.ExplainAnalyze)
+		if err != nil {
+			return result, fmt.Errorf("获取执行计划失败: %w", err)
+		}
+		for _, plan := range plans {
+			if plan.Regression {
+				slog.Warn("未按主键访问", "query", plan.Name, "access_type", plan.AccessType, "key", plan.Key, "sql", plan.SQL)
+			} else {
+				slog.Info("执行计划", "query", plan.Name, "access_type", plan.AccessType, "key", plan.Key)
+			}
+		}
+		result.QueryPlans = plans
+	}
+
+	// 存储占用：记录运行结束时压测表的数据、索引大小与每行占用空间
+	if config.Monitor.TableSize && conn.mysqlOnly("monitor.table_size") {
+		size, err := dals.TableSize(conn.db.WithContext(ctx), r.table, false, config.Monitor.FillFactor)
+		if err != nil {
+			return result, fmt.Errorf("统计表存储占用失败: %w", err)
+		}
+		size.Tier = tier
+		slog.Info("表存储占用", "table", size.Table, "rows_estimate", size.RowsEstimate, "data_bytes", size.DataLength,
+			"index_bytes", size.IndexLength, "free_bytes", size.DataFree, "bytes_per_row", size.BytesPerRow)
+		result.Tables = append(result.Tables, size)
+	}
+
+	return result, nil
+}
+
+// preloadTable 按行数估算值把压测表补齐到 spec.Rows 行，返回名为 preload 的阶段结果；已达到时返回 nil
+// ctx 结束时返回已插入部分的未完成结果
+func preloadTable(ctx context.Context, service services.Benchmark, spec *models.PreloadSpec) (*models.PhaseResult, error) {
+	rows, err := service.EstimateRows(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("估算表行数失败: %w", err)
+	}
+	missing := int64(spec.Rows) - rows
+	if missing <= 0 {
+		slog.Info("表已达到预填充行数，跳过预填充", "rows_estimate", rows, "rows", spec.Rows, "tier", spec.Tier)
+		return nil, nil
+	}
+	batchSize, concurrency := spec.BatchSize, spec.Concurrency
+	if batchSize == 0 {
+		batchSize = 1000
+	}
+	if concurrency == 0 {
+		concurrency = 16
+	}
+	slog.Info("开始预填充", "rows_estimate", rows, "rows", spec.Rows, "tier", spec.Tier, "batch_size", batchSize, "concurrency", concurrency)
+	phaseResult, err := service.Preload(ctx, int(missing), batchSize, concurrency)
+	if err != nil && (phaseResult == nil || !phaseResult.Incomplete) {
+		return nil, fmt.Errorf("预填充失败: %w", err)
+	}
+	slog.Info("预填充完成", "result", phaseResult)
+	return phaseResult, nil
+}
+
+// soak 以 w 中的时长、间隔与并发度执行长时间运行负载 op，时间序列追加写入 timeSeriesFile（按目标区分文件名）
+func soak(ctx context.Context, service services.Benchmark, op string, w *models.WorkloadConfig, timeSeriesFile, target string) (*models.PhaseResult, error) {
+	var timeSeries *utils.JSONLinesWriter
+	if timeSeriesFile != "" {
+		var err error
+		timeSeries, err = utils.NewJSONLinesWriter(targetFile(timeSeriesFile, target))
+		if err != nil {
+			return nil, fmt.Errorf("创建时间序列文件失败: %w", err)
+		}
+		defer timeSeries.Close()
+	}
+	onInterval := func(point *models.SoakPoint) {
+		slog.Info("soak 数据点", "elapsed_sec", math.Round(point.ElapsedSec), "ops_per_sec", point.OpsPerSec,
+			"p50_ms", point.Latency.P50Ms, "p99_ms", point.Latency.P99Ms, "errors", point.Errors, "rows", point.RowCount)
+		if timeSeries != nil {
+			if err := timeSeries.Write(point); err != nil {
+				slog.Warn("写入时间序列失败", "error", err)
+			}
+		}
+	}
+	return service.Soak(ctx, op, w.SoakDuration, w.SoakInterval, w.Concurrency, onInterval)
+}
+
+// runStep 以 timeout 为超时执行一个阶段（timeout <= 0 时不限制）
+// 阶段超时或 ctx 结束时不视为失败，返回已完成部分的 Incomplete 结果（准备数据时中断则为空的未完成结果），
+// 超时后运行继续，ctx 结束时由调用方停止后续阶段
+func runStep(ctx context.Context, name string, timeout time.Duration, step func(ctx context.Context) (*models.PhaseResult, error)) (*models.PhaseResult, error) {
+	stepCtx, cancel := ctx, context.CancelFunc(func() {})
+	if timeout > 0 {
+		stepCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+
+	result, err := step(stepCtx)
+	if err == nil || stepCtx.Err() == nil {
+		return result, err
+	}
+	if result == nil {
+		result = &models.PhaseResult{Phase: name, Incomplete: true}
+	}
+	result.Incomplete = true
+	if ctx.Err() == nil {
+		slog.Warn("阶段超时，记录已完成的部分", "phase", name, "timeout", timeout, "error", err)
+	}
+	return result, nil
+}
+
+// failedVerifications 返回数据校验未通过的阶段，多目标运行时带上目标名称
+func failedVerifications(set *models.RunSet) []string {
+	var failed []string
+	for _, run := range set.Runs {
+		for _, phase := range run.Phases {
+			if phase.Verification == nil || phase.Verification.Passed {
+				continue
+			}
+			if run.Target != "" {
+				failed = append(failed, run.Target+"/"+phase.Phase)
+			} else {
+				failed = append(failed, phase.Phase)
+			}
+		}
+	}
+	return failed
+}
+
+// phaseName 返回场景阶段在结果与日志中的名称
+func phaseName(spec models.PhaseSpec) string {
+	if spec.Name != "" {
+		return spec.Name
+	}
+	return spec.Op
+}
+
+// targetFile 在 path 的扩展名前加上目标名称，如 results/soak.jsonl -> results/soak-mysql84.jsonl，target 为空时原样返回
+func targetFile(path, target string) string {
+	if target == "" {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + target + ext
+}
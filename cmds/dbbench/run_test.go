@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"db_optimization_techs/pkgs/dals"
+	"db_optimization_techs/pkgs/models"
+
+	"gorm.io/gorm/logger"
+)
+
+// 某个目标失败时，之前目标的结果与失败目标已完成的部分仍写入结果文件
+func TestRunWritesPartialResultsOnTargetFailure(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.db")
+	db, err := dals.InitDB(&models.DatabaseConfig{Type: models.DatabaseSQLite, File: good}, logger.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dals.CreateTable(db, models.Test100mTable{}.TableName()); err != nil {
+		t.Fatal(err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+
+	config := map[string]any{
+		"database": map[string]any{"type": "sqlite", "file": good},
+		"targets": []map[string]any{
+			{"name": "good"},
+			// 目录不存在，连接失败
+			{"name": "bad", "database": map[string]any{"file": filepath.Join(dir, "missing", "bad.db")}},
+		},
+		"workload": map[string]any{"phase_ops": 20, "concurrency": 2, "seed": 1},
+		"log":      map[string]any{"output": filepath.Join(dir, "bench.log")},
+	}
+	content, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configFile := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configFile, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resultFile := filepath.Join(dir, "result.json")
+
+	err = runCommand([]string{"-conf", configFile, "-phases", "create,get", "-o", resultFile})
+	if err == nil {
+		t.Fatal("目标 bad 连接失败时 run 应返回错误")
+	}
+	data, readErr := os.ReadFile(resultFile)
+	if readErr != nil {
+		t.Fatalf("目标失败时也应写入结果文件: %v（run: %v）", readErr, err)
+	}
+	var set models.RunSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		t.Fatal(err)
+	}
+	if !set.Incomplete || len(set.Runs) != 2 {
+		t.Fatalf("结果应包含 2 个目标并标记为 incomplete: incomplete=%v runs=%d", set.Incomplete, len(set.Runs))
+	}
+	if run := set.Runs[0]; run.Incomplete || run.Error != "" || len(run.Phases) != 2 {
+		t.Fatalf("目标 good 应完整运行: incomplete=%v error=%q phases=%d", run.Incomplete, run.Error, len(run.Phases))
+	}
+	if run := set.Runs[1]; run.Target != "bad" || !run.Incomplete || run.Error == "" {
+		t.Fatalf("目标 bad 应标记为 incomplete 并记录错误: %+v", run)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"db_optimization_techs/pkgs/models"
+	"db_optimization_techs/pkgs/utils"
+)
+
+// scenarios 按名称注册的内置压测场景，新增场景只需在此添加一项；更复杂的场景可写成 YAML/JSON 文件，见 scenarios 目录
+var scenarios = map[string]models.Scenario{
+	"crud": {
+		Name:        "crud",
+		Description: "单条增删改查、Upsert 与事务读改写（场景 1）",
+		Phases:      opPhases("create", "get", "update", "upsert", "tx_rmw", "delete"),
+	},
+	"batch_insert": {
+		Name:        "batch_insert",
+		Description: "按 batch_total_rows 与 batch_size 批量插入（场景 2）",
+		Phases:      opPhases("insert_batch"),
+	},
+}
+
+// lookupScenario 按名称查找内置场景；以 .yaml、.yml、.json 结尾时作为场景文件读取并校验
+func lookupScenario(name string) (*models.Scenario, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		return utils.LoadScenario(name)
+	}
+	s, ok := scenarios[name]
+	if !ok {
+		names := make([]string, 0, len(scenarios))
+		for name := range scenarios {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("未知的压测场景: %q（可选 %s，或 YAML/JSON 场景文件路径）", name, strings.Join(names, "、"))
+	}
+	return &s, nil
+}
+
+// opPhases 返回依次执行 ops 的阶段列表，各阶段参数均取配置文件 workload 中的值
+func opPhases(ops ...string) []models.PhaseSpec {
+	phases := make([]models.PhaseSpec, 0, len(ops))
+	for _, op := range ops {
+		phases = append(phases, models.PhaseSpec{Op: op})
+	}
+	return phases
+}
+
+// splitList 解析逗号分隔的列表参数，忽略空项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
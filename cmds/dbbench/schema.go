@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"db_optimization_techs/pkgs/dals"
+	"db_optimization_techs/pkgs/models"
+)
+
+// schemaCommand 打印压测表的建表语句，指定 -apply 时在配置的数据库中执行
+// 指定 -scenario 时处理场景文件中的建表语句；配置的 MySQL 数据库需已存在，可先执行打印出的 CREATE DATABASE 语句，
+// SQLite 按 database.type 使用各自的建表语句（WITHOUT ROWID 表）
+func schemaCommand(args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	configFlags := addConfigFlags(fs)
+	strategyName := fs.String("strategy", "", "主键策略: uuid、crc32_uuid，为空时处理全部策略")
+	scenarioName := fs.String("scenario", "", "YAML/JSON 场景文件路径，处理其中的建表语句而不是主键策略的默认表")
+	apply := fs.Bool("apply", false, "在配置的数据库中执行建表语句")
+	fs.Parse(args)
+
+	if *scenarioName != "" {
+		return scenarioSchema(configFlags, *scenarioName, *apply)
+	}
+
+	names := strategyNames()
+	if *strategyName != "" {
+		names = []string{*strategyName}
+	}
+	var tables []string
+	for _, name := range names {
+		st, err := lookupStrategy(name)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, st.table)
+	}
+
+	config, err := loadConfig(configFlags)
+	if err != nil {
+		return err
+	}
+
+	if !*apply {
+		printDatabaseDDL(&config.Database)
+		for _, table := range tables {
+			ddl, err := dals.TableDDL(config.Database.Type, table)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("\n%s", ddl)
+		}
+		return nil
+	}
+
+	a, err := openApp(configFlags, "")
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	for _, table := range tables {
+		if err := dals.CreateTable(a.db, table); err != nil {
+			return err
+		}
+		slog.Info("表已创建", "table", table)
+	}
+	return nil
+}
+
+// scenarioSchema 打印或执行场景文件中的建表语句
+func scenarioSchema(configFlags *configFlags, name string, apply bool) error {
+	sc, err := lookupScenario(name)
+	if err != nil {
+		return err
+	}
+	if sc.DDL == "" {
+		return fmt.Errorf("场景 %s 没有建表语句", sc.Name)
+	}
+	table := sc.DDLTable()
+
+	if !apply {
+		config, err := loadConfig(configFlags)
+		if err != nil {
+			return err
+		}
+		printDatabaseDDL(&config.Database)
+		fmt.Printf("\n%s\n", strings.TrimSpace(sc.DDLFor(config.Database.Type)))
+		return nil
+	}
+
+	a, err := openApp(configFlags, "")
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	created, err := dals.ExecDDL(a.db, table, sc.DDLFor(a.config.Database.Type))
+	if err != nil {
+		return err
+	}
+	if created {
+		slog.Info("表已创建", "table", table)
+	} else {
+		slog.Info("表已存在，未修改", "table", table)
+	}
+	return nil
+}
+
+// printDatabaseDDL 打印建库与 USE 语句；SQLite 打开文件时即创建数据库，只打印注释
+func printDatabaseDDL(db *models.DatabaseConfig) {
+	if db.Type == models.DatabaseSQLite {
+		fmt.Printf("-- SQLite 数据库 %s，打开时自动创建\n", db.File)
+		return
+	}
+	fmt.Print(dals.DatabaseDDL(db.Database))
+	fmt.Printf("\nUSE %s;\n", db.Database)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"db_optimization_techs/pkgs/dals"
+	"db_optimization_techs/pkgs/models"
+	"db_optimization_techs/pkgs/services"
+
+	"gorm.io/gorm"
+)
+
+// strategy 主键策略：对应的压测表、压测服务与执行计划使用的 SQL 形态
+// table 为默认压测表；场景文件可指定列相同的其他表，newService、sampleUUID、queryShapes 均按传入的表操作
+type strategy struct {
+	table       string
+	newService  func(db *gorm.DB, table string) services.Benchmark
+	sampleUUID  func(ctx context.Context, db *gorm.DB, table string) (string, error)
+	queryShapes func(table, sampleUUID string) []dals.QueryShape
+}
+
+// strategies 按名称注册的主键策略，新增策略只需在此添加一项
+var strategies = map[string]strategy{
+	"uuid": {
+		table: models.Test100mTable{}.TableName(),
+		newService: func(db *gorm.DB, table string) services.Benchmark {
+			return services.NewTest100mService(dals.NewTest100mDAL(db).WithTable(table))
+		},
+		sampleUUID: func(ctx context.Context, db *gorm.DB, table string) (string, error) {
+			return dals.NewTest100mDAL(db).WithTable(table).SampleUUID(ctx)
+		},
+		queryShapes: dals.Test100mQueryShapes,
+	},
+	"crc32_uuid": {
+		table: models.Test100mCrc32Table{}.TableName(),
+		newService: func(db *gorm.DB, table string) services.Benchmark {
+			return services.NewTest100mCrc32Service(dals.NewTest100mCrc32DAL(db).WithTable(table))
+		},
+		sampleUUID: func(ctx context.Context, db *gorm.DB, table string) (string, error) {
+			return dals.NewTest100mCrc32DAL(db).WithTable(table).SampleUUID(ctx)
+		},
+		queryShapes: dals.Test100mCrc32QueryShapes,
+	},
+}
+
+// lookupStrategy 按名称查找主键策略
+func lookupStrategy(name string) (strategy, error) {
+	s, ok := strategies[name]
+	if !ok {
+		return strategy{}, fmt.Errorf("未知的主键策略: %q（可选 %s）", name, strings.Join(strategyNames(), "、"))
+	}
+	return s, nil
+}
+
+// strategyNames 返回已注册的策略名，按字母排序
+func strategyNames() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,151 @@
+package dals
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"db_optimization_techs/pkgs/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlCaptureCallback 捕获 SQL 的回调名称
+const sqlCaptureCallback = "dals:capture_sql"
+
+// errDryRun DryRun 会话中意外访问数据库时返回的错误
+var errDryRun = errors.New("dry run 模式下不会访问数据库")
+
+// CapturedSQL DryRun 会话中生成的一条 SQL 及其参数
+type CapturedSQL struct {
+	SQL  string        // 带占位符的 SQL
+	Vars []interface{} // 占位符参数
+}
+
+// sqlCaptureKey 在 Statement.Context 中保存捕获结果的键
+type sqlCaptureKey struct{}
+
+// sqlSink 收集捕获到的 SQL
+type sqlSink struct {
+	mu         sync.Mutex
+	statements []CapturedSQL
+}
+
+// QueryShape DAL 发出的一种 SQL 形态及其示例调用
+type QueryShape struct {
+	Name string                  // 形态名称，如 "Test100mDAL.GetByUUID"
+	Call func(db *gorm.DB) error // 基于给定连接构造 DAL 并执行一次示例调用
+}
+
+// CaptureSQL 在 DryRun 会话中执行 call，返回其生成的全部 SQL，不会访问数据库
+// 会话使用不连接数据库的连接池，因此 call 中开启事务也是安全的
+func CaptureSQL(db *gorm.DB, call func(dry *gorm.DB) error) ([]CapturedSQL, error) {
+	if err := registerSQLCapture(db); err != nil {
+		return nil, err
+	}
+
+	sink := &sqlSink{}
+	dry := db.Session(&gorm.Session{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		Context:                context.WithValue(context.Background(), sqlCaptureKey{}, sink),
+	})
+	dry.Statement.ConnPool = &dryRunConnPool{}
+
+	if err := call(dry); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return sink.statements, nil
+}
+
+// OpenDryRun 返回不连接数据库的会话，dbType 决定 SQL 方言（models.DatabaseMySQL、models.DatabaseSQLite），只能用于 CaptureSQL 生成 SQL
+// MySQL 不查询服务端版本、不 ping，因此无需数据库可达，也不需要密码；SQLite 方言初始化时需要查询版本，打开一个临时的内存库
+func OpenDryRun(dbType string, gormLogger logger.Interface) (*gorm.DB, error) {
+	if dbType == models.DatabaseSQLite {
+		return gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+			Logger: gormLogger,
+			DryRun: true,
+		})
+	}
+	return gorm.Open(mysql.New(mysql.Config{
+		Conn:                      &dryRunConnPool{},
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		Logger:               gormLogger,
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+}
+
+// registerSQLCapture 在 db 上注册捕获 SQL 的回调（只注册一次），回调仅在带有捕获上下文的会话中生效
+func registerSQLCapture(db *gorm.DB) error {
+	if db.Callback().Query().Get(sqlCaptureCallback) != nil {
+		return nil
+	}
+
+	capture := func(tx *gorm.DB) {
+		sink, ok := tx.Statement.Context.Value(sqlCaptureKey{}).(*sqlSink)
+		if !ok || tx.Statement.SQL.Len() == 0 {
+			return
+		}
+		sink.mu.Lock()
+		sink.statements = append(sink.statements, CapturedSQL{
+			SQL:  tx.Statement.SQL.String(),
+			Vars: append([]interface{}(nil), tx.Statement.Vars...),
+		})
+		sink.mu.Unlock()
+	}
+
+	callback := db.Callback()
+	if err := callback.Create().After("gorm:create").Register(sqlCaptureCallback, capture); err != nil {
+		return err
+	}
+	if err := callback.Query().After("gorm:query").Register(sqlCaptureCallback, capture); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register(sqlCaptureCallback, capture); err != nil {
+		return err
+	}
+	if err := callback.Delete().After("gorm:delete").Register(sqlCaptureCallback, capture); err != nil {
+		return err
+	}
+	if err := callback.Raw().After("gorm:raw").Register(sqlCaptureCallback, capture); err != nil {
+		return err
+	}
+	return callback.Row().After("gorm:row").Register(sqlCaptureCallback, capture)
+}
+
+// dryRunConnPool 不连接数据库的连接池，开启事务时返回 dryRunTx
+type dryRunConnPool struct{}
+
+func (*dryRunConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errDryRun
+}
+
+func (*dryRunConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, errDryRun
+}
+
+func (*dryRunConnPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errDryRun
+}
+
+func (*dryRunConnPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func (*dryRunConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return &dryRunTx{}, nil
+}
+
+// dryRunTx DryRun 会话中的空事务，提交与回滚均不做任何事
+type dryRunTx struct {
+	dryRunConnPool
+}
+
+func (*dryRunTx) Commit() error   { return nil }
+func (*dryRunTx) Rollback() error { return nil }
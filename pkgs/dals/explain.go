@@ -0,0 +1,135 @@
+package dals
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"db_optimization_techs/pkgs/models"
+
+	"gorm.io/gorm"
+)
+
+// ExplainQueryShapes 对 shapes 生成的每条不同 SQL 执行一次 EXPLAIN FORMAT=JSON，返回执行计划
+// analyze 为 true 时对不加锁的 SELECT 额外执行 EXPLAIN ANALYZE（MySQL 8.0.18+，会真正执行查询）；
+// 主键访问的语句若访问类型不是 const/eq_ref（UPDATE/DELETE 允许主键 range）会被标记为 Regression
+func ExplainQueryShapes(db *gorm.DB, shapes []QueryShape, analyze bool) ([]*models.QueryPlan, error) {
+	var plans []*models.QueryPlan
+	seen := make(map[string]bool)
+	for _, shape := range shapes {
+		statements, err := CaptureSQL(db, shape.Call)
+		if err != nil {
+			return plans, fmt.Errorf("获取 %s 的 SQL 失败: %w", shape.Name, err)
+		}
+		for i, statement := range statements {
+			if seen[statement.SQL] {
+				continue
+			}
+			seen[statement.SQL] = true
+
+			name := shape.Name
+			if len(statements) > 1 {
+				name = fmt.Sprintf("%s#%d", shape.Name, i+1)
+			}
+			plan, err := explainStatement(db, name, statement, analyze)
+			if err != nil {
+				return plans, err
+			}
+			plans = append(plans, plan)
+		}
+	}
+	return plans, nil
+}
+
+// explainStatement 执行 EXPLAIN 并解析访问类型与使用的索引
+func explainStatement(db *gorm.DB, name string, statement CapturedSQL, analyze bool) (*models.QueryPlan, error) {
+	var planJSON string
+	err := db.Raw("EXPLAIN FORMAT=JSON "+statement.SQL, statement.Vars...).Row().Scan(&planJSON)
+	if err != nil {
+		return nil, fmt.Errorf("EXPLAIN %s 失败: %w", name, err)
+	}
+
+	plan := &models.QueryPlan{
+		Name: name,
+		SQL:  statement.SQL,
+		Plan: json.RawMessage(planJSON),
+	}
+	plan.AccessType, plan.Key = parseAccess(planJSON)
+	checkRegression(plan)
+
+	verb := sqlVerb(statement.SQL)
+	if analyze && verb == "SELECT" && !strings.Contains(strings.ToUpper(statement.SQL), "FOR UPDATE") {
+		if err := db.Raw("EXPLAIN ANALYZE "+statement.SQL, statement.Vars...).Row().Scan(&plan.Analyze); err != nil {
+			return nil, fmt.Errorf("EXPLAIN ANALYZE %s 失败: %w", name, err)
+		}
+	}
+	return plan, nil
+}
+
+// parseAccess 从 EXPLAIN FORMAT=JSON 的结果中找到第一个表节点，返回其访问类型与使用的索引
+// 主键等值查询在记录不存在时，MySQL 只给出 "no matching row in const table"，此时按 const 处理
+func parseAccess(planJSON string) (accessType, key string) {
+	var root map[string]interface{}
+	if err := json.Unmarshal([]byte(planJSON), &root); err != nil {
+		return "", ""
+	}
+	if table := findTable(root); table != nil {
+		accessType, _ = table["access_type"].(string)
+		key, _ = table["key"].(string)
+		return accessType, key
+	}
+	if block, ok := root["query_block"].(map[string]interface{}); ok {
+		if message, _ := block["message"].(string); strings.Contains(message, "const table") {
+			return "const", "PRIMARY"
+		}
+	}
+	return "", ""
+}
+
+// findTable 递归查找 JSON 计划中第一个 "table" 节点
+func findTable(node map[string]interface{}) map[string]interface{} {
+	if table, ok := node["table"].(map[string]interface{}); ok {
+		return table
+	}
+	for _, value := range node {
+		switch child := value.(type) {
+		case map[string]interface{}:
+			if table := findTable(child); table != nil {
+				return table
+			}
+		case []interface{}:
+			for _, item := range child {
+				if m, ok := item.(map[string]interface{}); ok {
+					if table := findTable(m); table != nil {
+						return table
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkRegression 检查按主键访问的语句是否走了预期的访问路径，INSERT 不做检查
+func checkRegression(plan *models.QueryPlan) {
+	verb := sqlVerb(plan.SQL)
+	switch verb {
+	case "SELECT":
+		plan.Checked = true
+		plan.Regression = plan.AccessType != "const" && plan.AccessType != "eq_ref"
+	case "UPDATE", "DELETE":
+		// 单表 UPDATE/DELETE 的主键等值条件在 EXPLAIN 中显示为 PRIMARY 上的 range
+		plan.Checked = true
+		plan.Regression = plan.AccessType != "const" && plan.AccessType != "eq_ref" &&
+			!(plan.AccessType == "range" && plan.Key == "PRIMARY")
+	}
+}
+
+// sqlVerb 返回 SQL 的首个关键字（大写）
+func sqlVerb(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
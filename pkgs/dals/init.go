@@ -2,42 +2,48 @@ package dals
 
 import (
 	"fmt"
-	"log"
-	"os"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"db_optimization_techs/pkgs/models"
 
+	"github.com/glebarez/sqlite"
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+// sqliteBusyTimeout SQLite 写锁被其他连接持有时的等待时间，超时后返回 SQLITE_BUSY
+const sqliteBusyTimeout = 5 * time.Second
+
 // InitDB 初始化数据库连接
-// 根据配置创建 GORM 数据库连接并配置连接池
-func InitDB(cfg *models.DatabaseConfig) (*gorm.DB, error) {
-	// 构建 DSN 连接字符串
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.User,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.Database,
-	)
-
-	newLogger := logger.New(
-		log.New(os.Stdout, "\r\n", log.LstdFlags),
-		logger.Config{
-			SlowThreshold: 2 * time.Second,
-			LogLevel:      logger.Warn,
-			Colorful:      true,
-		},
-	)
+// 根据配置创建 GORM 数据库连接并配置连接池，gormLogger 为 nil 时使用 GORM 默认日志
+func InitDB(cfg *models.DatabaseConfig, gormLogger logger.Interface) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch cfg.Type {
+	case models.DatabaseSQLite:
+		dialector = sqlite.Open(BuildSQLiteDSN(cfg))
+	default:
+		dsn, err := BuildDSN(cfg)
+		if err != nil {
+			return nil, err
+		}
+		dialector = mysql.Open(dsn)
+	}
 
 	// 打开数据库连接
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: newLogger})
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger:                 gormLogger,
+		PrepareStmt:            cfg.Gorm.PrepareStmt,
+		SkipDefaultTransaction: cfg.Gorm.SkipDefaultTransaction,
+		CreateBatchSize:        cfg.Gorm.CreateBatchSize,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("连接数据库失败: %w", err)
+		return nil, fmt.Errorf("连接数据库失败: %w", redactError(err, cfg.Password))
 	}
 
 	// 获取底层 *sql.DB 以配置连接池
@@ -47,9 +53,85 @@ func InitDB(cfg *models.DatabaseConfig) (*gorm.DB, error) {
 	}
 
 	// 配置连接池参数
-	sqlDB.SetMaxOpenConns(100)          // 最大打开连接数
-	sqlDB.SetMaxIdleConns(10)           // 最大空闲连接数
-	sqlDB.SetConnMaxLifetime(time.Hour) // 连接最大生存时间
+	sqlDB.SetMaxOpenConns(cfg.Pool.MaxOpenConns)       // 最大打开连接数
+	sqlDB.SetMaxIdleConns(cfg.Pool.MaxIdleConns)       // 最大空闲连接数
+	sqlDB.SetConnMaxLifetime(cfg.Pool.ConnMaxLifetime) // 连接最大生存时间
+	sqlDB.SetConnMaxIdleTime(cfg.Pool.ConnMaxIdleTime) // 连接最大空闲时间
+
+	// 内存库只存在于打开它的连接中，只保留一个永不关闭的连接，所有操作在该连接上串行执行
+	if cfg.Type == models.DatabaseSQLite && isSQLiteMemory(cfg.File) {
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetConnMaxIdleTime(0)
+	}
 
 	return db, nil
 }
+
+// BuildSQLiteDSN 根据配置构建 SQLite 驱动的 DSN
+// 文件库使用 WAL 日志，读写可以并发；写事务以 BEGIN IMMEDIATE 开始，在事务开头等待写锁，
+// 避免读改写事务在升级为写锁时因 SQLITE_BUSY 直接失败
+func BuildSQLiteDSN(cfg *models.DatabaseConfig) string {
+	params := url.Values{}
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", sqliteBusyTimeout.Milliseconds()))
+	if !isSQLiteMemory(cfg.File) {
+		params.Add("_pragma", "journal_mode(WAL)")
+	}
+	params.Set("_txlock", "immediate")
+	return cfg.File + "?" + params.Encode()
+}
+
+// isSQLiteMemory 判断 SQLite 数据库文件是否为内存库
+func isSQLiteMemory(file string) bool {
+	return file == ":memory:"
+}
+
+// BuildDSN 根据配置构建 MySQL 驱动的 DSN 连接字符串
+// 用户名、密码中的特殊字符由驱动负责转义
+func BuildDSN(cfg *models.DatabaseConfig) (string, error) {
+	loc, err := time.LoadLocation(cfg.DSN.Loc)
+	if err != nil {
+		return "", fmt.Errorf("无效的时区 %q: %w", cfg.DSN.Loc, err)
+	}
+
+	c := mysqldriver.NewConfig()
+	c.User = cfg.User
+	c.Passwd = cfg.Password
+	c.Net = "tcp"
+	c.Addr = net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	c.DBName = cfg.Database
+	c.ParseTime = true
+	c.Loc = loc
+	c.Timeout = cfg.DSN.Timeout
+	c.ReadTimeout = cfg.DSN.ReadTimeout
+	c.WriteTimeout = cfg.DSN.WriteTimeout
+	c.InterpolateParams = cfg.DSN.InterpolateParams
+	// 影响行数按匹配的行计算而非实际变化的行，写入相同值的 UPDATE 也返回 1，便于按影响行数校验写入是否生效
+	c.ClientFoundRows = true
+	c.TLSConfig = cfg.DSN.TLS
+	if cfg.DSN.Charset != "" {
+		c.Params = map[string]string{"charset": cfg.DSN.Charset}
+	}
+	return c.FormatDSN(), nil
+}
+
+// redactedError 隐去错误信息中的密码，保留原始错误供 errors.Is/As 判断
+type redactedError struct {
+	err    error
+	secret string
+}
+
+func (e *redactedError) Error() string {
+	return strings.ReplaceAll(e.err.Error(), e.secret, "******")
+}
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redactError 当错误信息中包含 secret（如 DSN 解析失败时带出的密码）时将其隐去
+func redactError(err error, secret string) error {
+	if secret == "" || !strings.Contains(err.Error(), secret) {
+		return err
+	}
+	return &redactedError{err: err, secret: secret}
+}
@@ -0,0 +1,142 @@
+package dals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"db_optimization_techs/pkgs/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// defaultSlowThreshold 未配置时的慢查询阈值
+const defaultSlowThreshold = 2 * time.Second
+
+// 慢查询处理方式
+const (
+	SlowQueryLog   = "log"   // 逐条打印慢查询
+	SlowQueryCount = "count" // 只计数，不打印
+	SlowQueryOff   = "off"   // 不识别慢查询
+)
+
+// GormLogger 把 GORM 日志写入 slog 的结构化日志，并统计慢查询次数
+// 批量插入等大语句容易超过慢查询阈值，逐条打印本身会干扰压测，此时可使用 count 模式只计数
+type GormLogger struct {
+	logger        *slog.Logger
+	level         logger.LogLevel
+	slowThreshold time.Duration
+	slowMode      string
+	slowQueries   *atomic.Int64
+}
+
+// NewGormLogger 按日志配置创建 GormLogger，日志写入 slog 默认日志
+func NewGormLogger(cfg *models.LogConfig) (*GormLogger, error) {
+	level, err := parseGormLevel(cfg.GormLevel)
+	if err != nil {
+		return nil, err
+	}
+	slowMode := cfg.SlowQueryMode
+	switch slowMode {
+	case "":
+		slowMode = SlowQueryLog
+	case SlowQueryLog, SlowQueryCount, SlowQueryOff:
+	default:
+		return nil, fmt.Errorf("不支持的慢查询处理方式: %q（可选 log、count、off）", cfg.SlowQueryMode)
+	}
+	slowThreshold := cfg.SlowThreshold
+	if slowThreshold <= 0 {
+		slowThreshold = defaultSlowThreshold
+	}
+
+	return &GormLogger{
+		logger:        slog.Default().With("component", "gorm"),
+		level:         level,
+		slowThreshold: slowThreshold,
+		slowMode:      slowMode,
+		slowQueries:   &atomic.Int64{},
+	}, nil
+}
+
+// parseGormLevel 解析 GORM 日志级别，为空时为 warn
+func parseGormLevel(level string) (logger.LogLevel, error) {
+	switch strings.ToLower(level) {
+	case "silent":
+		return logger.Silent, nil
+	case "error":
+		return logger.Error, nil
+	case "", "warn":
+		return logger.Warn, nil
+	case "info":
+		return logger.Info, nil
+	default:
+		return 0, fmt.Errorf("不支持的 GORM 日志级别: %q（可选 silent、error、warn、info）", level)
+	}
+}
+
+// SlowQueries 返回累计的慢查询次数，count 与 log 模式下均会计数
+func (l *GormLogger) SlowQueries() int64 {
+	return l.slowQueries.Load()
+}
+
+// LogMode 实现 logger.Interface，返回使用新级别的副本，副本与原实例共享慢查询计数
+func (l *GormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+// Info 实现 logger.Interface
+func (l *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Info {
+		l.logger.InfoContext(ctx, fmt.Sprintf(msg, data...), "caller", utils.FileWithLineNum())
+	}
+}
+
+// Warn 实现 logger.Interface
+func (l *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Warn {
+		l.logger.WarnContext(ctx, fmt.Sprintf(msg, data...), "caller", utils.FileWithLineNum())
+	}
+}
+
+// Error 实现 logger.Interface
+func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Error {
+		l.logger.ErrorContext(ctx, fmt.Sprintf(msg, data...), "caller", utils.FileWithLineNum())
+	}
+}
+
+// Trace 实现 logger.Interface，在每条 SQL 执行后调用：记录失败的 SQL，统计并按模式打印慢查询
+// 未找到记录是查询的正常结果，不记录为错误
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+	slow := l.slowMode != SlowQueryOff && elapsed > l.slowThreshold
+	if slow {
+		l.slowQueries.Add(1)
+	}
+	if l.level <= logger.Silent {
+		return
+	}
+
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		l.logger.ErrorContext(ctx, "SQL 执行失败", "error", err, "elapsed_ms", elapsed.Milliseconds(),
+			"rows", rows, "sql", sql, "caller", utils.FileWithLineNum())
+	case slow && l.slowMode == SlowQueryLog && l.level >= logger.Warn:
+		sql, rows := fc()
+		l.logger.WarnContext(ctx, "慢查询", "threshold", l.slowThreshold, "elapsed_ms", elapsed.Milliseconds(),
+			"rows", rows, "sql", sql, "caller", utils.FileWithLineNum())
+	case l.level >= logger.Info:
+		sql, rows := fc()
+		l.logger.InfoContext(ctx, "SQL", "elapsed_ms", elapsed.Milliseconds(), "rows", rows, "sql", sql,
+			"caller", utils.FileWithLineNum())
+	}
+}
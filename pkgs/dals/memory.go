@@ -0,0 +1,434 @@
+package dals
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"db_optimization_techs/pkgs/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrInjected 内存 DAL 按 MemoryOptions.ErrorRate 注入的默认错误
+var ErrInjected = errors.New("内存 DAL 注入的错误")
+
+// ErrDuplicateKey 内存 DAL 插入已存在的主键时返回的错误
+var ErrDuplicateKey = errors.New("主键重复")
+
+// MemoryOptions 内存 DAL 的延迟与错误注入参数，零值表示无延迟、不注入错误
+type MemoryOptions struct {
+	Latency   time.Duration // 每次调用的固定延迟，用于模拟网络与服务端耗时
+	Jitter    time.Duration // 在 Latency 之上额外增加 [0, Jitter) 的随机延迟
+	ErrorRate float64       // 每次调用失败的概率，取值 [0, 1]；失败的调用不修改数据
+	Err       error         // 注入的错误，为 nil 时为 ErrInjected；可设为死锁等错误以测试重试逻辑
+	FailOps   []string      // 只对这些方法注入错误，如 "ReadModifyWrite"，为空时对所有方法注入
+	Seed      uint64        // 延迟抖动与错误注入的随机种子；调用次数相同时注入的错误数相同
+}
+
+// memoryRow 内存表中一行的非主键列
+type memoryRow struct {
+	name, email, nickname string
+}
+
+// memoryTable 以 map 保存的线程安全内存表，按 MemoryOptions 为每次调用注入延迟与错误
+// 事务读改写持有整张表的写锁，即各事务串行执行，不会出现死锁
+type memoryTable struct {
+	opts MemoryOptions
+
+	mu   sync.RWMutex
+	rows map[string]memoryRow
+
+	randMu sync.Mutex
+	rand   *rand.Rand
+
+	calls    atomic.Int64
+	injected atomic.Int64
+}
+
+func newMemoryTable(opts MemoryOptions) *memoryTable {
+	return &memoryTable{
+		opts: opts,
+		rows: make(map[string]memoryRow),
+		rand: rand.New(rand.NewPCG(opts.Seed, opts.Seed)),
+	}
+}
+
+// fault 在方法 op 的每次调用开始时执行：等待注入的延迟（ctx 先结束时返回 ctx.Err()），再按概率返回注入的错误
+func (t *memoryTable) fault(ctx context.Context, op string) error {
+	t.calls.Add(1)
+	t.randMu.Lock()
+	delay := t.opts.Latency
+	if t.opts.Jitter > 0 {
+		delay += time.Duration(t.rand.Int64N(int64(t.opts.Jitter)))
+	}
+	fail := t.opts.ErrorRate > 0 && (len(t.opts.FailOps) == 0 || slices.Contains(t.opts.FailOps, op)) &&
+		t.rand.Float64() < t.opts.ErrorRate
+	t.randMu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if fail {
+		t.injected.Add(1)
+		if t.opts.Err != nil {
+			return t.opts.Err
+		}
+		return ErrInjected
+	}
+	return nil
+}
+
+// insert 插入 keys 对应的行，任一主键已存在（或在 keys 中重复）时不插入任何行
+func (t *memoryTable) insert(keys []string, rows []memoryRow) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if _, ok := t.rows[key]; ok || seen[key] {
+			return fmt.Errorf("%w: %s", ErrDuplicateKey, key)
+		}
+		seen[key] = true
+	}
+	for i, key := range keys {
+		t.rows[key] = rows[i]
+	}
+	return nil
+}
+
+func (t *memoryTable) get(key string) (memoryRow, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	row, ok := t.rows[key]
+	return row, ok
+}
+
+// update 更新已存在的行，返回影响的行数
+func (t *memoryTable) update(key string, row memoryRow) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.rows[key]; !ok {
+		return 0
+	}
+	t.rows[key] = row
+	return 1
+}
+
+func (t *memoryTable) upsert(key string, row memoryRow) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rows[key] = row
+}
+
+// readModifyWrite 持有写锁依次修改 keys 对应的行，不存在的行以零值交给 modify 后插入，返回写入的行数
+func (t *memoryTable) readModifyWrite(keys []string, modify func(key string, row memoryRow) memoryRow) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, key := range keys {
+		t.rows[key] = modify(key, t.rows[key])
+	}
+	return int64(len(keys))
+}
+
+func (t *memoryTable) delete(key string) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.rows[key]; !ok {
+		return 0
+	}
+	delete(t.rows, key)
+	return 1
+}
+
+func (t *memoryTable) count(keys []string) int64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var n int64
+	for _, key := range keys {
+		if _, ok := t.rows[key]; ok {
+			n++
+		}
+	}
+	return n
+}
+
+func (t *memoryTable) len() int64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return int64(len(t.rows))
+}
+
+// sample 返回任意一个主键，表为空时返回 gorm.ErrRecordNotFound
+func (t *memoryTable) sample() (string, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for key := range t.rows {
+		return key, nil
+	}
+	return "", gorm.ErrRecordNotFound
+}
+
+// MemoryTest100mDAL Test100mStore 的内存实现，用于在没有数据库的情况下测试服务层的并发、错误汇总与计时逻辑
+type MemoryTest100mDAL struct {
+	table *memoryTable
+}
+
+// NewMemoryTest100mDAL 创建空的内存表，opts 为延迟与错误注入参数
+func NewMemoryTest100mDAL(opts MemoryOptions) *MemoryTest100mDAL {
+	return &MemoryTest100mDAL{table: newMemoryTable(opts)}
+}
+
+// Calls 返回累计调用次数（含注入失败的调用）
+func (dal *MemoryTest100mDAL) Calls() int64 { return dal.table.calls.Load() }
+
+// Injected 返回累计注入的错误次数
+func (dal *MemoryTest100mDAL) Injected() int64 { return dal.table.injected.Load() }
+
+// Len 返回当前行数
+func (dal *MemoryTest100mDAL) Len() int64 { return dal.table.len() }
+
+// Create 创建记录
+func (dal *MemoryTest100mDAL) Create(ctx context.Context, record *models.Test100mTable) error {
+	if err := dal.table.fault(ctx, "Create"); err != nil {
+		return err
+	}
+	return dal.table.insert([]string{record.Uuid}, []memoryRow{{record.Name, record.Email, record.Nickname}})
+}
+
+// InsertBatch 一次插入 records 中的全部记录，任一主键已存在时整批失败
+func (dal *MemoryTest100mDAL) InsertBatch(ctx context.Context, records []*models.Test100mTable) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if err := dal.table.fault(ctx, "InsertBatch"); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(records))
+	rows := make([]memoryRow, 0, len(records))
+	for _, record := range records {
+		keys = append(keys, record.Uuid)
+		rows = append(rows, memoryRow{record.Name, record.Email, record.Nickname})
+	}
+	return dal.table.insert(keys, rows)
+}
+
+// GetByUUID 根据 UUID 查询记录
+func (dal *MemoryTest100mDAL) GetByUUID(ctx context.Context, uuid string) (*models.Test100mTable, error) {
+	if err := dal.table.fault(ctx, "GetByUUID"); err != nil {
+		return nil, err
+	}
+	row, ok := dal.table.get(uuid)
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &models.Test100mTable{Uuid: uuid, Name: row.name, Email: row.email, Nickname: row.nickname}, nil
+}
+
+// Update 按主键更新记录，返回影响的行数
+func (dal *MemoryTest100mDAL) Update(ctx context.Context, record *models.Test100mTable) (int64, error) {
+	if err := dal.table.fault(ctx, "Update"); err != nil {
+		return 0, err
+	}
+	return dal.table.update(record.Uuid, memoryRow{record.Name, record.Email, record.Nickname}), nil
+}
+
+// Upsert 插入记录，主键冲突时更新
+func (dal *MemoryTest100mDAL) Upsert(ctx context.Context, record *models.Test100mTable) error {
+	if err := dal.table.fault(ctx, "Upsert"); err != nil {
+		return err
+	}
+	dal.table.upsert(record.Uuid, memoryRow{record.Name, record.Email, record.Nickname})
+	return nil
+}
+
+// ReadModifyWrite 串行地读取 uuids 并用 modify 修改后写回，不存在的记录插入；opts 被忽略
+func (dal *MemoryTest100mDAL) ReadModifyWrite(ctx context.Context, uuids []string, opts *sql.TxOptions, modify func(record *models.Test100mTable)) (int64, error) {
+	if err := dal.table.fault(ctx, "ReadModifyWrite"); err != nil {
+		return 0, err
+	}
+	return dal.table.readModifyWrite(uuids, func(key string, row memoryRow) memoryRow {
+		record := models.Test100mTable{Uuid: key, Name: row.name, Email: row.email, Nickname: row.nickname}
+		modify(&record)
+		return memoryRow{record.Name, record.Email, record.Nickname}
+	}), nil
+}
+
+// Delete 根据 UUID 删除记录，返回影响的行数
+func (dal *MemoryTest100mDAL) Delete(ctx context.Context, uuid string) (int64, error) {
+	if err := dal.table.fault(ctx, "Delete"); err != nil {
+		return 0, err
+	}
+	return dal.table.delete(uuid), nil
+}
+
+// CountByUUIDs 返回 uuids 中存在的记录数
+func (dal *MemoryTest100mDAL) CountByUUIDs(ctx context.Context, uuids []string) (int64, error) {
+	if err := dal.table.fault(ctx, "CountByUUIDs"); err != nil {
+		return 0, err
+	}
+	return dal.table.count(uuids), nil
+}
+
+// EstimateRows 返回当前的精确行数
+func (dal *MemoryTest100mDAL) EstimateRows(ctx context.Context) (int64, error) {
+	if err := dal.table.fault(ctx, "EstimateRows"); err != nil {
+		return 0, err
+	}
+	return dal.table.len(), nil
+}
+
+// SampleUUID 返回任意一条记录的 UUID
+func (dal *MemoryTest100mDAL) SampleUUID(ctx context.Context) (string, error) {
+	if err := dal.table.fault(ctx, "SampleUUID"); err != nil {
+		return "", err
+	}
+	return dal.table.sample()
+}
+
+// MemoryTest100mCrc32DAL Test100mCrc32Store 的内存实现，uuid_crc32 由 UUID 计算，查询时校验其与 UUID 一致
+type MemoryTest100mCrc32DAL struct {
+	table *memoryTable
+}
+
+// NewMemoryTest100mCrc32DAL 创建空的内存表，opts 为延迟与错误注入参数
+func NewMemoryTest100mCrc32DAL(opts MemoryOptions) *MemoryTest100mCrc32DAL {
+	return &MemoryTest100mCrc32DAL{table: newMemoryTable(opts)}
+}
+
+// Calls 返回累计调用次数（含注入失败的调用）
+func (dal *MemoryTest100mCrc32DAL) Calls() int64 { return dal.table.calls.Load() }
+
+// Injected 返回累计注入的错误次数
+func (dal *MemoryTest100mCrc32DAL) Injected() int64 { return dal.table.injected.Load() }
+
+// Len 返回当前行数
+func (dal *MemoryTest100mCrc32DAL) Len() int64 { return dal.table.len() }
+
+// crc32Record 返回 uuid 对应的完整记录
+func crc32Record(uuid string, row memoryRow) *models.Test100mCrc32Table {
+	return &models.Test100mCrc32Table{
+		UuidCrc32: crc32.ChecksumIEEE([]byte(uuid)),
+		Uuid:      uuid,
+		Name:      row.name,
+		Email:     row.email,
+		Nickname:  row.nickname,
+	}
+}
+
+// Create 创建记录，自动计算 uuid_crc32
+func (dal *MemoryTest100mCrc32DAL) Create(ctx context.Context, record *models.Test100mCrc32Table) error {
+	record.UuidCrc32 = crc32.ChecksumIEEE([]byte(record.Uuid))
+	if err := dal.table.fault(ctx, "Create"); err != nil {
+		return err
+	}
+	return dal.table.insert([]string{record.Uuid}, []memoryRow{{record.Name, record.Email, record.Nickname}})
+}
+
+// InsertBatch 一次插入 records 中的全部记录，任一主键已存在时整批失败
+func (dal *MemoryTest100mCrc32DAL) InsertBatch(ctx context.Context, records []*models.Test100mCrc32Table) error {
+	if len(records) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(records))
+	rows := make([]memoryRow, 0, len(records))
+	for _, record := range records {
+		record.UuidCrc32 = crc32.ChecksumIEEE([]byte(record.Uuid))
+		keys = append(keys, record.Uuid)
+		rows = append(rows, memoryRow{record.Name, record.Email, record.Nickname})
+	}
+	if err := dal.table.fault(ctx, "InsertBatch"); err != nil {
+		return err
+	}
+	return dal.table.insert(keys, rows)
+}
+
+// GetByCrc32AndUUID 根据 CRC32 和 UUID 查询记录，CRC32 与 UUID 不匹配时视为不存在
+func (dal *MemoryTest100mCrc32DAL) GetByCrc32AndUUID(ctx context.Context, crc32Value uint32, uuid string) (*models.Test100mCrc32Table, error) {
+	if err := dal.table.fault(ctx, "GetByCrc32AndUUID"); err != nil {
+		return nil, err
+	}
+	row, ok := dal.table.get(uuid)
+	if !ok || crc32Value != crc32.ChecksumIEEE([]byte(uuid)) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return crc32Record(uuid, row), nil
+}
+
+// Update 按联合主键更新记录，返回影响的行数
+func (dal *MemoryTest100mCrc32DAL) Update(ctx context.Context, record *models.Test100mCrc32Table) (int64, error) {
+	record.UuidCrc32 = crc32.ChecksumIEEE([]byte(record.Uuid))
+	if err := dal.table.fault(ctx, "Update"); err != nil {
+		return 0, err
+	}
+	return dal.table.update(record.Uuid, memoryRow{record.Name, record.Email, record.Nickname}), nil
+}
+
+// Upsert 插入记录，联合主键冲突时更新
+func (dal *MemoryTest100mCrc32DAL) Upsert(ctx context.Context, record *models.Test100mCrc32Table) error {
+	record.UuidCrc32 = crc32.ChecksumIEEE([]byte(record.Uuid))
+	if err := dal.table.fault(ctx, "Upsert"); err != nil {
+		return err
+	}
+	dal.table.upsert(record.Uuid, memoryRow{record.Name, record.Email, record.Nickname})
+	return nil
+}
+
+// ReadModifyWrite 串行地读取 uuids 并用 modify 修改后写回，不存在的记录插入；opts 被忽略
+func (dal *MemoryTest100mCrc32DAL) ReadModifyWrite(ctx context.Context, uuids []string, opts *sql.TxOptions, modify func(record *models.Test100mCrc32Table)) (int64, error) {
+	if err := dal.table.fault(ctx, "ReadModifyWrite"); err != nil {
+		return 0, err
+	}
+	return dal.table.readModifyWrite(uuids, func(key string, row memoryRow) memoryRow {
+		record := crc32Record(key, row)
+		modify(record)
+		return memoryRow{record.Name, record.Email, record.Nickname}
+	}), nil
+}
+
+// Delete 根据 UUID 删除记录，返回影响的行数
+func (dal *MemoryTest100mCrc32DAL) Delete(ctx context.Context, uuid string) (int64, error) {
+	if err := dal.table.fault(ctx, "Delete"); err != nil {
+		return 0, err
+	}
+	return dal.table.delete(uuid), nil
+}
+
+// CountByUUIDs 返回 uuids 中存在的记录数
+func (dal *MemoryTest100mCrc32DAL) CountByUUIDs(ctx context.Context, uuids []string) (int64, error) {
+	if err := dal.table.fault(ctx, "CountByUUIDs"); err != nil {
+		return 0, err
+	}
+	return dal.table.count(uuids), nil
+}
+
+// EstimateRows 返回当前的精确行数
+func (dal *MemoryTest100mCrc32DAL) EstimateRows(ctx context.Context) (int64, error) {
+	if err := dal.table.fault(ctx, "EstimateRows"); err != nil {
+		return 0, err
+	}
+	return dal.table.len(), nil
+}
+
+// SampleUUID 返回任意一条记录的 UUID
+func (dal *MemoryTest100mCrc32DAL) SampleUUID(ctx context.Context) (string, error) {
+	if err := dal.table.fault(ctx, "SampleUUID"); err != nil {
+		return "", err
+	}
+	return dal.table.sample()
+}
@@ -0,0 +1,184 @@
+package dals
+
+import (
+	"context"
+	"errors"
+	"hash/crc32"
+	"sync"
+	"testing"
+	"time"
+
+	"db_optimization_techs/pkgs/models"
+)
+
+func TestMemoryTest100mDALCRUD(t *testing.T) {
+	ctx := context.Background()
+	dal := NewMemoryTest100mDAL(MemoryOptions{})
+
+	record := &models.Test100mTable{Uuid: "a", Name: "Name_0", Email: "email_0@test.com", Nickname: "Nickname_0"}
+	if err := dal.Create(ctx, record); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := dal.Create(ctx, record); !errors.Is(err, ErrDuplicateKey) {
+		t.Fatalf("重复 Create 应返回 ErrDuplicateKey，实际为 %v", err)
+	}
+
+	got, err := dal.GetByUUID(ctx, "a")
+	if err != nil || *got != *record {
+		t.Fatalf("GetByUUID = %+v, %v，预期 %+v", got, err, record)
+	}
+	if _, err := dal.GetByUUID(ctx, "missing"); !IsNotFound(err) {
+		t.Fatalf("查询不存在的记录应返回 not found，实际为 %v", err)
+	}
+
+	// 更新与删除不存在的记录影响 0 行，不会插入
+	if n, err := dal.Update(ctx, &models.Test100mTable{Uuid: "missing"}); n != 0 || err != nil {
+		t.Fatalf("Update 不存在的记录 = %d, %v，预期 0, nil", n, err)
+	}
+	if n, err := dal.Update(ctx, &models.Test100mTable{Uuid: "a", Name: "UpdatedName_0"}); n != 1 || err != nil {
+		t.Fatalf("Update = %d, %v，预期 1, nil", n, err)
+	}
+	if n, _ := dal.Delete(ctx, "missing"); n != 0 {
+		t.Fatalf("Delete 不存在的记录影响 %d 行，预期 0", n)
+	}
+	if dal.Len() != 1 {
+		t.Fatalf("Len = %d，预期 1", dal.Len())
+	}
+
+	if err := dal.Upsert(ctx, &models.Test100mTable{Uuid: "b", Name: "UpsertName_1"}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if n, _ := dal.CountByUUIDs(ctx, []string{"a", "b", "c"}); n != 2 {
+		t.Fatalf("CountByUUIDs = %d，预期 2", n)
+	}
+	if n, _ := dal.Delete(ctx, "a"); n != 1 {
+		t.Fatalf("Delete 影响 %d 行，预期 1", n)
+	}
+	if n, _ := dal.EstimateRows(ctx); n != 1 {
+		t.Fatalf("EstimateRows = %d，预期 1", n)
+	}
+}
+
+func TestMemoryTest100mDALInsertBatchIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	dal := NewMemoryTest100mDAL(MemoryOptions{})
+	if err := dal.Create(ctx, &models.Test100mTable{Uuid: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	records := []*models.Test100mTable{{Uuid: "a"}, {Uuid: "b"}, {Uuid: "c"}}
+	if err := dal.InsertBatch(ctx, records); !errors.Is(err, ErrDuplicateKey) {
+		t.Fatalf("包含已存在主键的 InsertBatch 应失败，实际为 %v", err)
+	}
+	if dal.Len() != 1 {
+		t.Fatalf("失败的批量插入不应写入任何行，Len = %d", dal.Len())
+	}
+}
+
+func TestMemoryTest100mDALReadModifyWrite(t *testing.T) {
+	ctx := context.Background()
+	dal := NewMemoryTest100mDAL(MemoryOptions{})
+	if err := dal.Create(ctx, &models.Test100mTable{Uuid: "a", Name: "Original"}); err != nil {
+		t.Fatal(err)
+	}
+	n, err := dal.ReadModifyWrite(ctx, []string{"a", "b"}, nil, func(record *models.Test100mTable) {
+		record.Name += "+tx"
+	})
+	if n != 2 || err != nil {
+		t.Fatalf("ReadModifyWrite = %d, %v，预期 2, nil", n, err)
+	}
+	a, _ := dal.GetByUUID(ctx, "a")
+	b, _ := dal.GetByUUID(ctx, "b")
+	if a.Name != "Original+tx" || b == nil || b.Name != "+tx" {
+		t.Fatalf("读改写后 a=%+v b=%+v", a, b)
+	}
+}
+
+func TestMemoryTest100mCrc32DALChecksCrc32(t *testing.T) {
+	ctx := context.Background()
+	dal := NewMemoryTest100mCrc32DAL(MemoryOptions{})
+	record := &models.Test100mCrc32Table{Uuid: "a", Name: "Name_0"}
+	if err := dal.Create(ctx, record); err != nil {
+		t.Fatal(err)
+	}
+	sum := crc32.ChecksumIEEE([]byte("a"))
+	if record.UuidCrc32 != sum {
+		t.Fatalf("Create 应回填 uuid_crc32，实际为 %d", record.UuidCrc32)
+	}
+	got, err := dal.GetByCrc32AndUUID(ctx, sum, "a")
+	if err != nil || got.UuidCrc32 != sum || got.Name != "Name_0" {
+		t.Fatalf("GetByCrc32AndUUID = %+v, %v", got, err)
+	}
+	if _, err := dal.GetByCrc32AndUUID(ctx, sum+1, "a"); !IsNotFound(err) {
+		t.Fatalf("CRC32 不匹配时应返回 not found，实际为 %v", err)
+	}
+}
+
+func TestMemoryOptionsErrorInjection(t *testing.T) {
+	ctx := context.Background()
+	t.Run("全部失败且不修改数据", func(t *testing.T) {
+		dal := NewMemoryTest100mDAL(MemoryOptions{ErrorRate: 1})
+		if err := dal.Create(ctx, &models.Test100mTable{Uuid: "a"}); !errors.Is(err, ErrInjected) {
+			t.Fatalf("Create 应返回 ErrInjected，实际为 %v", err)
+		}
+		if dal.Len() != 0 || dal.Injected() != 1 || dal.Calls() != 1 {
+			t.Fatalf("Len=%d Injected=%d Calls=%d", dal.Len(), dal.Injected(), dal.Calls())
+		}
+	})
+
+	t.Run("只对指定方法注入自定义错误", func(t *testing.T) {
+		errCustom := errors.New("custom")
+		dal := NewMemoryTest100mDAL(MemoryOptions{ErrorRate: 1, Err: errCustom, FailOps: []string{"Delete"}})
+		if err := dal.Create(ctx, &models.Test100mTable{Uuid: "a"}); err != nil {
+			t.Fatalf("Create 不应注入错误: %v", err)
+		}
+		if _, err := dal.Delete(ctx, "a"); !errors.Is(err, errCustom) {
+			t.Fatalf("Delete 应返回注入的错误，实际为 %v", err)
+		}
+	})
+
+	t.Run("相同种子注入的错误数相同", func(t *testing.T) {
+		count := func() int64 {
+			dal := NewMemoryTest100mDAL(MemoryOptions{ErrorRate: 0.3, Seed: 42})
+			var wg sync.WaitGroup
+			for w := 0; w < 8; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 100; i++ {
+						dal.CountByUUIDs(ctx, []string{"a"})
+					}
+				}()
+			}
+			wg.Wait()
+			return dal.Injected()
+		}
+		first := count()
+		if first == 0 || first == 800 {
+			t.Fatalf("错误率 0.3 下 800 次调用注入了 %d 次错误", first)
+		}
+		if second := count(); second != first {
+			t.Fatalf("相同种子两次注入的错误数不同: %d != %d", first, second)
+		}
+	})
+}
+
+func TestMemoryOptionsLatencyHonorsContext(t *testing.T) {
+	dal := NewMemoryTest100mDAL(MemoryOptions{Latency: 20 * time.Millisecond})
+
+	start := time.Now()
+	if _, err := dal.EstimateRows(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("注入 20ms 延迟，调用只耗时 %s", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := dal.Create(ctx, &models.Test100mTable{Uuid: "a"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("延迟期间 ctx 超时应返回 DeadlineExceeded，实际为 %v", err)
+	}
+	if dal.Len() != 0 {
+		t.Fatal("超时的调用不应写入数据")
+	}
+}
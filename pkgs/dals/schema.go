@@ -0,0 +1,64 @@
+package dals
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+
+	"db_optimization_techs/pkgs/models"
+
+	"gorm.io/gorm"
+)
+
+// schemaFS 各压测表的建表语句，文件名为表名；schema/ 下为 MySQL 的语句，schema/sqlite/ 下为 SQLite 的语句
+//
+//go:embed schema/*.sql schema/sqlite/*.sql
+var schemaFS embed.FS
+
+// TableDDL 返回 table 在数据库类型 dbType（models.DatabaseMySQL、models.DatabaseSQLite）上的建表语句
+func TableDDL(dbType, table string) (string, error) {
+	dir := "schema/"
+	if dbType == models.DatabaseSQLite {
+		dir = "schema/sqlite/"
+	}
+	data, err := schemaFS.ReadFile(dir + table + ".sql")
+	if err != nil {
+		return "", fmt.Errorf("没有表 %s 的 %s 建表语句", table, dbType)
+	}
+	return string(data), nil
+}
+
+// DatabaseDDL 返回创建数据库 database 的语句
+func DatabaseDDL(database string) string {
+	return fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s\n    CHARACTER SET utf8mb4\n    COLLATE utf8mb4_unicode_ci;\n", database)
+}
+
+// CreateTable 在当前连接的数据库中执行 table 的建表语句（按连接的数据库类型选择），表已存在时不做修改
+func CreateTable(db *gorm.DB, table string) error {
+	ddl, err := TableDDL(db.Dialector.Name(), table)
+	if err != nil {
+		return err
+	}
+	_, err = ExecDDL(db, table, ddl)
+	return err
+}
+
+// ExecDDL 表 table 不存在时执行建表语句 ddl（可包含 -- 注释行），返回是否新建了表
+// 用于场景文件中自定义的建表语句，即使 ddl 中没有写 IF NOT EXISTS 也可以重复执行
+func ExecDDL(db *gorm.DB, table, ddl string) (created bool, err error) {
+	if db.Migrator().HasTable(table) {
+		return false, nil
+	}
+	// 去掉注释行，只保留 CREATE TABLE 语句本身
+	var lines []string
+	for _, line := range strings.Split(ddl, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+	stmt := strings.TrimSuffix(strings.TrimSpace(strings.Join(lines, "\n")), ";")
+	if err := db.Exec(stmt).Error; err != nil {
+		return false, fmt.Errorf("创建表 %s 失败: %w", table, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,10 @@
+-- 创建表 test_100m_crc32_table（SQLite）：crc32(uuid) + uuid 联合主键
+-- WITHOUT ROWID 使表本身按 (uuid_crc32, uuid) 组织成 B 树，与 InnoDB 的聚簇索引相同
+CREATE TABLE IF NOT EXISTS test_100m_crc32_table (
+    uuid_crc32 INTEGER,
+    uuid TEXT,
+    name TEXT,
+    email TEXT,
+    nickname TEXT,
+    PRIMARY KEY (uuid_crc32, uuid)  -- 联合主键
+) WITHOUT ROWID;
@@ -0,0 +1,8 @@
+-- 创建表 test_100m_table（SQLite）：UUID 单列主键
+-- WITHOUT ROWID 使表本身按 uuid 组织成 B 树，与 InnoDB 的聚簇索引相同；否则数据按隐藏的 rowid 存放，uuid 另建一个索引
+CREATE TABLE IF NOT EXISTS test_100m_table (
+    uuid TEXT PRIMARY KEY,
+    name TEXT,
+    email TEXT,
+    nickname TEXT
+) WITHOUT ROWID;
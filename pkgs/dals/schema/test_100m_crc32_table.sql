@@ -1,12 +1,4 @@
--- 创建数据库 test_100m_db
-CREATE DATABASE IF NOT EXISTS test_100m_crc32_db
-    CHARACTER SET utf8mb4
-    COLLATE utf8mb4_unicode_ci;
-
--- 使用数据库
-USE test_100m_crc32_db;
-
--- 创建表 test_100m_table
+-- 创建表 test_100m_crc32_table：crc32(uuid) + uuid 联合主键
 CREATE TABLE IF NOT EXISTS test_100m_crc32_table (
     uuid_crc32 INT UNSIGNED,
     uuid VARCHAR(36),
@@ -0,0 +1,61 @@
+package dals
+
+import (
+	"fmt"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// innodbMetricPrefix INNODB_METRICS 计数器在快照中的键名前缀，用于与 SHOW GLOBAL STATUS 区分
+const innodbMetricPrefix = "innodb_metrics."
+
+// SnapshotServerStatus 读取 MySQL 的 SHOW GLOBAL STATUS 与 information_schema.INNODB_METRICS 中已启用的计数器
+// GLOBAL STATUS 以原变量名为键（如 Innodb_rows_inserted），INNODB_METRICS 以 "innodb_metrics." + NAME 为键；
+// 非数值的状态变量会被忽略
+func SnapshotServerStatus(db *gorm.DB) (map[string]int64, error) {
+	snapshot := make(map[string]int64)
+
+	var status []struct {
+		VariableName string `gorm:"column:Variable_name"`
+		Value        string `gorm:"column:Value"`
+	}
+	if err := db.Raw("SHOW GLOBAL STATUS").Scan(&status).Error; err != nil {
+		return nil, fmt.Errorf("读取 GLOBAL STATUS 失败: %w", err)
+	}
+	for _, row := range status {
+		if value, err := strconv.ParseInt(row.Value, 10, 64); err == nil {
+			snapshot[row.VariableName] = value
+		}
+	}
+
+	var metrics []struct {
+		Name  string `gorm:"column:NAME"`
+		Count int64  `gorm:"column:COUNT"`
+	}
+	err := db.Raw("SELECT NAME, COUNT FROM information_schema.INNODB_METRICS WHERE STATUS = 'enabled'").
+		Scan(&metrics).Error
+	if err != nil {
+		return nil, fmt.Errorf("读取 INNODB_METRICS 失败: %w", err)
+	}
+	for _, row := range metrics {
+		snapshot[innodbMetricPrefix+row.Name] = row.Count
+	}
+
+	return snapshot, nil
+}
+
+// EnableInnodbMetrics 启用指定的 INNODB_METRICS 计数器（如 index_page_splits 默认未启用），需要 SYSTEM_VARIABLES_ADMIN 权限
+func EnableInnodbMetrics(db *gorm.DB, names []string) error {
+	for _, name := range names {
+		if err := db.Exec("SET GLOBAL innodb_monitor_enable = ?", name).Error; err != nil {
+			return fmt.Errorf("启用 INNODB_METRICS 计数器 %s 失败: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// InnodbMetricKey 返回 INNODB_METRICS 计数器在快照中的键名
+func InnodbMetricKey(name string) string {
+	return innodbMetricPrefix + name
+}
@@ -0,0 +1,57 @@
+package dals
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"db_optimization_techs/pkgs/models"
+
+	"gorm.io/gorm/logger"
+)
+
+func TestSQLiteSchema(t *testing.T) {
+	db, err := InitDB(&models.DatabaseConfig{Type: models.DatabaseSQLite, File: ":memory:"}, logger.Discard)
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+	if sqlDB.Stats().MaxOpenConnections != 1 {
+		t.Fatalf("内存库应只使用一个连接，实际为 %d", sqlDB.Stats().MaxOpenConnections)
+	}
+
+	for _, table := range BenchmarkTables {
+		ddl, err := TableDDL(models.DatabaseSQLite, table)
+		if err != nil || !strings.Contains(ddl, "WITHOUT ROWID") {
+			t.Fatalf("%s 的 SQLite 建表语句应为 WITHOUT ROWID 表: %v\n%s", table, err, ddl)
+		}
+		mysqlDDL, err := TableDDL(models.DatabaseMySQL, table)
+		if err != nil || !strings.Contains(mysqlDDL, "ENGINE=InnoDB") {
+			t.Fatalf("%s 的 MySQL 建表语句: %v\n%s", table, err, mysqlDDL)
+		}
+		// 表已存在时不做修改，可以重复执行
+		for range 2 {
+			if err := CreateTable(db, table); err != nil {
+				t.Fatalf("CreateTable(%s): %v", table, err)
+			}
+		}
+	}
+
+	ctx := context.Background()
+	dal := NewTest100mCrc32DAL(db)
+	records := []*models.Test100mCrc32Table{{Uuid: "a"}, {Uuid: "b"}, {Uuid: "c"}}
+	if err := dal.InsertBatch(ctx, records); err != nil {
+		t.Fatalf("InsertBatch: %v", err)
+	}
+	// SQLite 没有行数统计，EstimateRows 为精确值
+	if n, err := dal.EstimateRows(ctx); n != 3 || err != nil {
+		t.Fatalf("EstimateRows = %d, %v，预期 3", n, err)
+	}
+	if _, err := dal.GetByCrc32AndUUID(ctx, records[0].UuidCrc32, "a"); err != nil {
+		t.Fatalf("按联合主键查询: %v", err)
+	}
+	if err := dal.Create(ctx, &models.Test100mCrc32Table{Uuid: "a"}); err == nil {
+		t.Fatal("重复的联合主键应插入失败")
+	}
+}
@@ -0,0 +1,61 @@
+package dals
+
+import (
+	"fmt"
+
+	"db_optimization_techs/pkgs/models"
+
+	"gorm.io/gorm"
+)
+
+// picosecondsPerMs performance_schema 计时器单位为皮秒
+const picosecondsPerMs = 1e9
+
+// ResetStatementDigests 清空 performance_schema.events_statements_summary_by_digest，需要对 performance_schema 的 DROP 权限
+func ResetStatementDigests(db *gorm.DB) error {
+	if err := db.Exec("TRUNCATE TABLE performance_schema.events_statements_summary_by_digest").Error; err != nil {
+		return fmt.Errorf("重置语句摘要失败: %w", err)
+	}
+	return nil
+}
+
+// StatementDigests 读取当前数据库上按总耗时降序的前 limit 条语句摘要
+func StatementDigests(db *gorm.DB, limit int) ([]*models.StatementDigest, error) {
+	var rows []struct {
+		Digest          string `gorm:"column:DIGEST"`
+		DigestText      string `gorm:"column:DIGEST_TEXT"`
+		CountStar       int64  `gorm:"column:COUNT_STAR"`
+		SumTimerWait    int64  `gorm:"column:SUM_TIMER_WAIT"`
+		AvgTimerWait    int64  `gorm:"column:AVG_TIMER_WAIT"`
+		MaxTimerWait    int64  `gorm:"column:MAX_TIMER_WAIT"`
+		SumLockTime     int64  `gorm:"column:SUM_LOCK_TIME"`
+		SumRowsExamined int64  `gorm:"column:SUM_ROWS_EXAMINED"`
+		SumRowsSent     int64  `gorm:"column:SUM_ROWS_SENT"`
+		SumRowsAffected int64  `gorm:"column:SUM_ROWS_AFFECTED"`
+	}
+	err := db.Raw(`SELECT DIGEST, DIGEST_TEXT, COUNT_STAR, SUM_TIMER_WAIT, AVG_TIMER_WAIT, MAX_TIMER_WAIT,
+			SUM_LOCK_TIME, SUM_ROWS_EXAMINED, SUM_ROWS_SENT, SUM_ROWS_AFFECTED
+		FROM performance_schema.events_statements_summary_by_digest
+		WHERE SCHEMA_NAME = DATABASE() AND DIGEST IS NOT NULL
+		ORDER BY SUM_TIMER_WAIT DESC LIMIT ?`, limit).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("读取语句摘要失败: %w", err)
+	}
+
+	digests := make([]*models.StatementDigest, 0, len(rows))
+	for _, row := range rows {
+		digests = append(digests, &models.StatementDigest{
+			Digest:         row.Digest,
+			DigestText:     row.DigestText,
+			Count:          row.CountStar,
+			TotalLatencyMs: float64(row.SumTimerWait) / picosecondsPerMs,
+			AvgLatencyMs:   float64(row.AvgTimerWait) / picosecondsPerMs,
+			MaxLatencyMs:   float64(row.MaxTimerWait) / picosecondsPerMs,
+			LockTimeMs:     float64(row.SumLockTime) / picosecondsPerMs,
+			RowsExamined:   row.SumRowsExamined,
+			RowsSent:       row.SumRowsSent,
+			RowsAffected:   row.SumRowsAffected,
+		})
+	}
+	return digests, nil
+}
@@ -0,0 +1,65 @@
+package dals
+
+import (
+	"context"
+	"database/sql"
+
+	"db_optimization_techs/pkgs/models"
+)
+
+// Test100mStore test_100m_table 形态表的数据访问接口，服务层依赖该接口而非具体实现，
+// 由基于 GORM 的 Test100mDAL 与用于单元测试的 MemoryTest100mDAL 实现
+type Test100mStore interface {
+	// Create 创建记录，主键已存在时返回错误
+	Create(ctx context.Context, record *models.Test100mTable) error
+	// InsertBatch 一次插入 records 中的全部记录
+	InsertBatch(ctx context.Context, records []*models.Test100mTable) error
+	// GetByUUID 根据 UUID 查询记录，不存在时返回的错误满足 IsNotFound
+	GetByUUID(ctx context.Context, uuid string) (*models.Test100mTable, error)
+	// Update 按主键更新 name、email、nickname，返回影响的行数，记录不存在时为 0
+	Update(ctx context.Context, record *models.Test100mTable) (int64, error)
+	// Upsert 插入记录，主键冲突时更新 name、email、nickname
+	Upsert(ctx context.Context, record *models.Test100mTable) error
+	// ReadModifyWrite 在一个事务内锁定读取 uuids 并用 modify 修改后写回，不存在的记录插入，返回写入的总行数
+	ReadModifyWrite(ctx context.Context, uuids []string, opts *sql.TxOptions, modify func(record *models.Test100mTable)) (int64, error)
+	// Delete 根据 UUID 删除记录，返回影响的行数
+	Delete(ctx context.Context, uuid string) (int64, error)
+	// CountByUUIDs 返回 uuids 中在表里存在的记录数
+	CountByUUIDs(ctx context.Context, uuids []string) (int64, error)
+	// EstimateRows 返回表行数的估算值
+	EstimateRows(ctx context.Context) (int64, error)
+	// SampleUUID 返回表中任意一条记录的 UUID，表为空时返回的错误满足 IsNotFound
+	SampleUUID(ctx context.Context) (string, error)
+}
+
+// Test100mCrc32Store test_100m_crc32_table 形态表的数据访问接口，
+// 由基于 GORM 的 Test100mCrc32DAL 与用于单元测试的 MemoryTest100mCrc32DAL 实现；uuid_crc32 均由实现计算
+type Test100mCrc32Store interface {
+	// Create 创建记录，主键已存在时返回错误
+	Create(ctx context.Context, record *models.Test100mCrc32Table) error
+	// InsertBatch 一次插入 records 中的全部记录
+	InsertBatch(ctx context.Context, records []*models.Test100mCrc32Table) error
+	// GetByCrc32AndUUID 根据 CRC32 和 UUID 查询记录，不存在时返回的错误满足 IsNotFound
+	GetByCrc32AndUUID(ctx context.Context, crc32 uint32, uuid string) (*models.Test100mCrc32Table, error)
+	// Update 按联合主键更新 name、email、nickname，返回影响的行数，记录不存在时为 0
+	Update(ctx context.Context, record *models.Test100mCrc32Table) (int64, error)
+	// Upsert 插入记录，联合主键冲突时更新 name、email、nickname
+	Upsert(ctx context.Context, record *models.Test100mCrc32Table) error
+	// ReadModifyWrite 在一个事务内锁定读取 uuids 并用 modify 修改后写回，不存在的记录插入，返回写入的总行数
+	ReadModifyWrite(ctx context.Context, uuids []string, opts *sql.TxOptions, modify func(record *models.Test100mCrc32Table)) (int64, error)
+	// Delete 根据 UUID 删除记录，返回影响的行数
+	Delete(ctx context.Context, uuid string) (int64, error)
+	// CountByUUIDs 返回 uuids 中在表里存在的记录数
+	CountByUUIDs(ctx context.Context, uuids []string) (int64, error)
+	// EstimateRows 返回表行数的估算值
+	EstimateRows(ctx context.Context) (int64, error)
+	// SampleUUID 返回表中任意一条记录的 UUID，表为空时返回的错误满足 IsNotFound
+	SampleUUID(ctx context.Context) (string, error)
+}
+
+var (
+	_ Test100mStore      = (*Test100mDAL)(nil)
+	_ Test100mStore      = (*MemoryTest100mDAL)(nil)
+	_ Test100mCrc32Store = (*Test100mCrc32DAL)(nil)
+	_ Test100mCrc32Store = (*MemoryTest100mCrc32DAL)(nil)
+)
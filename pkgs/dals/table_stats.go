@@ -0,0 +1,119 @@
+package dals
+
+import (
+	"errors"
+	"fmt"
+
+	"db_optimization_techs/pkgs/models"
+
+	"gorm.io/gorm"
+)
+
+// BenchmarkTables 所有压测表的表名
+var BenchmarkTables = []string{
+	models.Test100mTable{}.TableName(),
+	models.Test100mCrc32Table{}.TableName(),
+}
+
+// ErrTableNotFound 当前数据库中不存在指定的表
+var ErrTableNotFound = errors.New("表不存在")
+
+// estimateTableRows 从 information_schema 读取表行数的估算值，避免在亿级数据上执行 COUNT(*)
+// MySQL 8.0 默认缓存表统计信息，这里在同一连接上关闭缓存以取得最新估算值；
+// SQLite 没有维护中的行数统计，直接执行 COUNT(*)
+func estimateTableRows(db *gorm.DB, table string) (int64, error) {
+	var rows int64
+	if db.Dialector.Name() == models.DatabaseSQLite {
+		err := db.Table(table).Count(&rows).Error
+		return rows, err
+	}
+	err := db.Connection(func(conn *gorm.DB) error {
+		// 使用 Session 使两条语句的错误互不影响；MySQL 5.7 没有该变量，忽略设置失败
+		session := conn.Session(&gorm.Session{})
+		session.Exec("SET SESSION information_schema_stats_expiry = 0")
+		return session.Raw("SELECT COALESCE(TABLE_ROWS, 0) FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?", table).
+			Scan(&rows).Error
+	})
+	return rows, err
+}
+
+// TableSize 读取当前 MySQL 数据库中 table 的行数估算、数据与索引大小、碎片空间和平均行长
+// analyze 为 true 时先执行 ANALYZE TABLE 刷新统计信息；
+// fillFactor 为 true 时根据 INNODB_BUFFER_PAGE 中已缓存的聚簇索引页估算页填充率（只统计缓冲池中的页，查询开销较大）
+func TableSize(db *gorm.DB, table string, analyze, fillFactor bool) (*models.TableSize, error) {
+	if analyze {
+		if err := db.Exec(fmt.Sprintf("ANALYZE TABLE `%s`", table)).Error; err != nil {
+			return nil, fmt.Errorf("ANALYZE TABLE %s 失败: %w", table, err)
+		}
+	}
+
+	var rows []struct {
+		TableSchema  string `gorm:"column:TABLE_SCHEMA"`
+		TableRows    int64  `gorm:"column:TABLE_ROWS"`
+		AvgRowLength int64  `gorm:"column:AVG_ROW_LENGTH"`
+		DataLength   int64  `gorm:"column:DATA_LENGTH"`
+		IndexLength  int64  `gorm:"column:INDEX_LENGTH"`
+		DataFree     int64  `gorm:"column:DATA_FREE"`
+	}
+	err := db.Connection(func(conn *gorm.DB) error {
+		session := conn.Session(&gorm.Session{})
+		session.Exec("SET SESSION information_schema_stats_expiry = 0")
+		return session.Raw(`SELECT TABLE_SCHEMA, COALESCE(TABLE_ROWS, 0) AS TABLE_ROWS, COALESCE(AVG_ROW_LENGTH, 0) AS AVG_ROW_LENGTH,
+			COALESCE(DATA_LENGTH, 0) AS DATA_LENGTH, COALESCE(INDEX_LENGTH, 0) AS INDEX_LENGTH, COALESCE(DATA_FREE, 0) AS DATA_FREE
+			FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?`, table).
+			Scan(&rows).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 的表信息失败: %w", table, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTableNotFound, table)
+	}
+
+	row := rows[0]
+	size := &models.TableSize{
+		Schema:       row.TableSchema,
+		Table:        table,
+		RowsEstimate: row.TableRows,
+		AvgRowLength: row.AvgRowLength,
+		DataLength:   row.DataLength,
+		IndexLength:  row.IndexLength,
+		DataFree:     row.DataFree,
+	}
+	if size.RowsEstimate > 0 {
+		size.BytesPerRow = float64(size.DataLength+size.IndexLength) / float64(size.RowsEstimate)
+	}
+
+	if fillFactor {
+		if err := bufferPoolFillFactor(db, size); err != nil {
+			return size, err
+		}
+	}
+	return size, nil
+}
+
+// bufferPoolFillFactor 统计 INNODB_BUFFER_PAGE 中该表聚簇索引（PRIMARY）已缓存页的数据量，估算页填充率
+func bufferPoolFillFactor(db *gorm.DB, size *models.TableSize) error {
+	var pageSize int64
+	if err := db.Raw("SELECT @@innodb_page_size").Row().Scan(&pageSize); err != nil {
+		return fmt.Errorf("读取 innodb_page_size 失败: %w", err)
+	}
+
+	var pages struct {
+		Pages    int64 `gorm:"column:pages"`
+		DataSize int64 `gorm:"column:data_size"`
+	}
+	err := db.Raw(`SELECT COUNT(*) AS pages, COALESCE(SUM(DATA_SIZE), 0) AS data_size
+		FROM information_schema.INNODB_BUFFER_PAGE
+		WHERE TABLE_NAME = ? AND INDEX_NAME = 'PRIMARY' AND PAGE_TYPE = 'INDEX'`,
+		fmt.Sprintf("`%s`.`%s`", size.Schema, size.Table)).Scan(&pages).Error
+	if err != nil {
+		return fmt.Errorf("读取 %s 的缓冲池页信息失败: %w", size.Table, err)
+	}
+
+	size.CachedPages = pages.Pages
+	if pages.Pages > 0 {
+		size.FillFactor = float64(pages.DataSize) / float64(pages.Pages*pageSize)
+	}
+	return nil
+}
@@ -1,60 +1,200 @@
 package dals
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"hash/crc32"
 
 	"db_optimization_techs/pkgs/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Test100mCrc32DAL 数据访问层，用于操作 test_100m_crc32_table 表
+// 各方法的 ctx 用于取消与超时，同时作为链路追踪 span 的父级
 type Test100mCrc32DAL struct {
-	db *gorm.DB
+	db    *gorm.DB
+	table string
 }
 
 // NewTest100mCrc32DAL 创建 Test100mCrc32DAL 实例
 func NewTest100mCrc32DAL(db *gorm.DB) *Test100mCrc32DAL {
-	return &Test100mCrc32DAL{db: db}
+	return &Test100mCrc32DAL{db: db, table: models.Test100mCrc32Table{}.TableName()}
+}
+
+// WithTable 返回操作表 table 的 DAL，用于场景文件中自定义建表语句的表（如只在索引上不同）
+// table 的列须与 Test100mCrc32Table 一致
+func (dal *Test100mCrc32DAL) WithTable(table string) *Test100mCrc32DAL {
+	return &Test100mCrc32DAL{db: dal.db.Table(table).Session(&gorm.Session{}), table: table}
 }
 
 // Create 创建记录，自动计算 uuid_crc32
-func (dal *Test100mCrc32DAL) Create(record *models.Test100mCrc32Table) error {
+func (dal *Test100mCrc32DAL) Create(ctx context.Context, record *models.Test100mCrc32Table) error {
 	// 自动计算 UUID 的 CRC32 值
 	record.UuidCrc32 = crc32.ChecksumIEEE([]byte(record.Uuid))
-	return dal.db.Create(record).Error
+	return withTraceKey(dal.db.WithContext(ctx), record.Uuid).Create(record).Error
+}
+
+// InsertBatch 用一条多行 INSERT 插入 records 中的全部记录，自动计算每条记录的 uuid_crc32
+func (dal *Test100mCrc32DAL) InsertBatch(ctx context.Context, records []*models.Test100mCrc32Table) error {
+	if len(records) == 0 {
+		return nil
+	}
+	for _, record := range records {
+		record.UuidCrc32 = crc32.ChecksumIEEE([]byte(record.Uuid))
+	}
+	return dal.db.WithContext(ctx).CreateInBatches(records, len(records)).Error
 }
 
 // GetByCrc32AndUUID 根据 CRC32 和 UUID 查询记录（直接使用联合主键）
-func (dal *Test100mCrc32DAL) GetByCrc32AndUUID(crc32 uint32, uuid string) (*models.Test100mCrc32Table, error) {
+func (dal *Test100mCrc32DAL) GetByCrc32AndUUID(ctx context.Context, crc32 uint32, uuid string) (*models.Test100mCrc32Table, error) {
 	var record models.Test100mCrc32Table
-	err := dal.db.Where("uuid_crc32 = ? AND uuid = ?", crc32, uuid).First(&record).Error
+	err := withTraceKey(dal.db.WithContext(ctx), uuid).Where("uuid_crc32 = ? AND uuid = ?", crc32, uuid).First(&record).Error
 	if err != nil {
 		return nil, err
 	}
 	return &record, nil
 }
 
-// Update 更新记录，自动更新 uuid_crc32（使用联合主键定位）
-func (dal *Test100mCrc32DAL) Update(record *models.Test100mCrc32Table) error {
+// Update 更新记录，自动更新 uuid_crc32（使用联合主键定位），返回影响的行数；记录不存在时影响行数为 0
+func (dal *Test100mCrc32DAL) Update(ctx context.Context, record *models.Test100mCrc32Table) (int64, error) {
 	// 如果 UUID 发生变化，重新计算 CRC32
 	record.UuidCrc32 = crc32.ChecksumIEEE([]byte(record.Uuid))
 	// 使用联合主键 (uuid_crc32, uuid) 定位记录并更新
-	return dal.db.Model(&models.Test100mCrc32Table{}).
+	result := withTraceKey(dal.db.WithContext(ctx), record.Uuid).Model(&models.Test100mCrc32Table{}).
 		Where("uuid_crc32 = ? AND uuid = ?", record.UuidCrc32, record.Uuid).
 		Updates(map[string]interface{}{
 			"name":     record.Name,
 			"email":    record.Email,
 			"nickname": record.Nickname,
-		}).Error
+		})
+	return result.RowsAffected, result.Error
 }
 
-// Delete 删除记录（使用联合主键 (uuid_crc32, uuid) 定位）
-func (dal *Test100mCrc32DAL) Delete(uuid string) error {
+// Upsert 插入记录，联合主键 (uuid_crc32, uuid) 冲突时更新 name、email、nickname
+// MySQL 生成 INSERT ... ON DUPLICATE KEY UPDATE，PostgreSQL、SQLite 生成 INSERT ... ON CONFLICT DO UPDATE
+func (dal *Test100mCrc32DAL) Upsert(ctx context.Context, record *models.Test100mCrc32Table) error {
+	record.UuidCrc32 = crc32.ChecksumIEEE([]byte(record.Uuid))
+	return withTraceKey(dal.db.WithContext(ctx), record.Uuid).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "uuid_crc32"}, {Name: "uuid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "email", "nickname"}),
+	}).Create(record).Error
+}
+
+// ReadModifyWrite 在一个事务内依次对 uuids 按联合主键执行 SELECT ... FOR UPDATE，再用 modify 修改后写回，返回写入的总行数
+// 记录存在时执行 UPDATE，不存在时执行 INSERT（此时锁定读会持有间隙锁）；SQLite 没有行锁，忽略 FOR UPDATE，事务开始时即持有库级写锁
+// opts 用于指定隔离级别，为 nil 时使用数据库默认隔离级别；死锁等错误原样返回，由调用方决定是否重试
+func (dal *Test100mCrc32DAL) ReadModifyWrite(ctx context.Context, uuids []string, opts *sql.TxOptions, modify func(record *models.Test100mCrc32Table)) (int64, error) {
+	var affected int64
+	err := dal.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, uuid := range uuids {
+			crc32Value := crc32.ChecksumIEEE([]byte(uuid))
+			var record models.Test100mCrc32Table
+			err := withTraceKey(tx, uuid).Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+				Where("uuid_crc32 = ? AND uuid = ?", crc32Value, uuid).First(&record).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				record = models.Test100mCrc32Table{UuidCrc32: crc32Value, Uuid: uuid}
+				modify(&record)
+				result := withTraceKey(tx, uuid).Create(&record)
+				if result.Error != nil {
+					return result.Error
+				}
+				affected += result.RowsAffected
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			modify(&record)
+			result := withTraceKey(tx, uuid).Model(&models.Test100mCrc32Table{}).
+				Where("uuid_crc32 = ? AND uuid = ?", crc32Value, uuid).
+				Updates(map[string]interface{}{
+					"name":     record.Name,
+					"email":    record.Email,
+					"nickname": record.Nickname,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			affected += result.RowsAffected
+		}
+		return nil
+	}, opts)
+	return affected, err
+}
+
+// Delete 删除记录（使用联合主键 (uuid_crc32, uuid) 定位），返回影响的行数
+func (dal *Test100mCrc32DAL) Delete(ctx context.Context, uuid string) (int64, error) {
 	// 计算 CRC32 后使用联合主键删除
 	crc32Value := crc32.ChecksumIEEE([]byte(uuid))
 	// 明确使用联合主键索引进行删除
-	return dal.db.Model(&models.Test100mCrc32Table{}).
+	result := withTraceKey(dal.db.WithContext(ctx), uuid).Model(&models.Test100mCrc32Table{}).
 		Where("uuid_crc32 = ? AND uuid = ?", crc32Value, uuid).
-		Delete(&models.Test100mCrc32Table{}).Error
+		Delete(&models.Test100mCrc32Table{})
+	return result.RowsAffected, result.Error
+}
+
+// CountByUUIDs 返回 uuids 中在表里存在的记录数，用于校验阶段写入或删除的结果；uuids 较多时由调用方分批
+// 同时以 uuid_crc32 过滤，使查询走联合主键
+func (dal *Test100mCrc32DAL) CountByUUIDs(ctx context.Context, uuids []string) (int64, error) {
+	crc32Values := make([]uint32, 0, len(uuids))
+	for _, uuid := range uuids {
+		crc32Values = append(crc32Values, crc32.ChecksumIEEE([]byte(uuid)))
+	}
+	var count int64
+	err := dal.db.WithContext(ctx).Model(&models.Test100mCrc32Table{}).
+		Where("uuid_crc32 IN ? AND uuid IN ?", crc32Values, uuids).Count(&count).Error
+	return count, err
+}
+
+// EstimateRows 返回表行数的估算值
+func (dal *Test100mCrc32DAL) EstimateRows(ctx context.Context) (int64, error) {
+	return estimateTableRows(dal.db.WithContext(ctx), dal.table)
+}
+
+// SampleUUID 返回表中任意一条记录的 UUID，用于以真实主键生成执行计划
+func (dal *Test100mCrc32DAL) SampleUUID(ctx context.Context) (string, error) {
+	var record models.Test100mCrc32Table
+	if err := dal.db.WithContext(ctx).Select("uuid").Take(&record).Error; err != nil {
+		return "", err
+	}
+	return record.Uuid, nil
+}
+
+// Test100mCrc32QueryShapes 返回 Test100mCrc32DAL 操作 table 时每个方法的示例调用，用于获取其生成的 SQL 形态
+func Test100mCrc32QueryShapes(table, sampleUUID string) []QueryShape {
+	newDAL := func(db *gorm.DB) *Test100mCrc32DAL { return NewTest100mCrc32DAL(db).WithTable(table) }
+	sample := func() *models.Test100mCrc32Table {
+		return &models.Test100mCrc32Table{Uuid: sampleUUID, Name: "Name", Email: "email@test.com", Nickname: "Nickname"}
+	}
+	return []QueryShape{
+		{Name: "Test100mCrc32DAL.Create", Call: func(db *gorm.DB) error {
+			return newDAL(db).Create(db.Statement.Context, sample())
+		}},
+		{Name: "Test100mCrc32DAL.InsertBatch", Call: func(db *gorm.DB) error {
+			return newDAL(db).InsertBatch(db.Statement.Context, []*models.Test100mCrc32Table{sample(), sample()})
+		}},
+		{Name: "Test100mCrc32DAL.GetByCrc32AndUUID", Call: func(db *gorm.DB) error {
+			_, err := newDAL(db).GetByCrc32AndUUID(db.Statement.Context, crc32.ChecksumIEEE([]byte(sampleUUID)), sampleUUID)
+			return err
+		}},
+		{Name: "Test100mCrc32DAL.Update", Call: func(db *gorm.DB) error {
+			_, err := newDAL(db).Update(db.Statement.Context, sample())
+			return err
+		}},
+		{Name: "Test100mCrc32DAL.Upsert", Call: func(db *gorm.DB) error {
+			return newDAL(db).Upsert(db.Statement.Context, sample())
+		}},
+		{Name: "Test100mCrc32DAL.ReadModifyWrite", Call: func(db *gorm.DB) error {
+			_, err := newDAL(db).ReadModifyWrite(db.Statement.Context, []string{sampleUUID}, nil, func(*models.Test100mCrc32Table) {})
+			return err
+		}},
+		{Name: "Test100mCrc32DAL.Delete", Call: func(db *gorm.DB) error {
+			_, err := newDAL(db).Delete(db.Statement.Context, sampleUUID)
+			return err
+		}},
+	}
 }
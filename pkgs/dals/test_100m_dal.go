@@ -1,48 +1,178 @@
 package dals
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+
 	"db_optimization_techs/pkgs/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Test100mDAL 数据访问层，用于操作 test_100m_table 表
+// 各方法的 ctx 用于取消与超时，同时作为链路追踪 span 的父级
 type Test100mDAL struct {
-	db *gorm.DB
+	db    *gorm.DB
+	table string
 }
 
 // NewTest100mDAL 创建 Test100mDAL 实例
 func NewTest100mDAL(db *gorm.DB) *Test100mDAL {
-	return &Test100mDAL{db: db}
+	return &Test100mDAL{db: db, table: models.Test100mTable{}.TableName()}
+}
+
+// WithTable 返回操作表 table 的 DAL，用于场景文件中自定义建表语句的表（如只在索引上不同）
+// table 的列须与 Test100mTable 一致
+func (dal *Test100mDAL) WithTable(table string) *Test100mDAL {
+	return &Test100mDAL{db: dal.db.Table(table).Session(&gorm.Session{}), table: table}
 }
 
 // Create 创建记录
-func (dal *Test100mDAL) Create(record *models.Test100mTable) error {
-	return dal.db.Create(record).Error
+func (dal *Test100mDAL) Create(ctx context.Context, record *models.Test100mTable) error {
+	return withTraceKey(dal.db.WithContext(ctx), record.Uuid).Create(record).Error
 }
 
-// InsertBatch100 批量插入多条记录（典型用法为 100 条），由调用方保证 records 长度与内容；DAL 内不强制校验 len(records)==100
-// 使用 CreateInBatches 确保每批 100 行对应一条 INSERT，实现真正的批量插入
-func (dal *Test100mDAL) InsertBatch100(records []*models.Test100mTable) error {
-	return dal.db.CreateInBatches(records, 100).Error
+// InsertBatch 用一条多行 INSERT 插入 records 中的全部记录，批大小由调用方决定
+func (dal *Test100mDAL) InsertBatch(ctx context.Context, records []*models.Test100mTable) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return dal.db.WithContext(ctx).CreateInBatches(records, len(records)).Error
 }
 
 // GetByUUID 根据 UUID 主键查询记录
-func (dal *Test100mDAL) GetByUUID(uuid string) (*models.Test100mTable, error) {
+func (dal *Test100mDAL) GetByUUID(ctx context.Context, uuid string) (*models.Test100mTable, error) {
 	var record models.Test100mTable
-	err := dal.db.Where("uuid = ?", uuid).First(&record).Error
+	err := withTraceKey(dal.db.WithContext(ctx), uuid).Where("uuid = ?", uuid).First(&record).Error
 	if err != nil {
 		return nil, err
 	}
 	return &record, nil
 }
 
-// Update 更新记录
-func (dal *Test100mDAL) Update(record *models.Test100mTable) error {
-	return dal.db.Save(record).Error
+// Update 按主键更新 name、email、nickname，返回影响的行数；记录不存在时不会插入，影响行数为 0
+func (dal *Test100mDAL) Update(ctx context.Context, record *models.Test100mTable) (int64, error) {
+	result := withTraceKey(dal.db.WithContext(ctx), record.Uuid).Model(&models.Test100mTable{}).
+		Where("uuid = ?", record.Uuid).
+		Updates(map[string]interface{}{
+			"name":     record.Name,
+			"email":    record.Email,
+			"nickname": record.Nickname,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// Upsert 插入记录，主键冲突时更新 name、email、nickname
+// MySQL 生成 INSERT ... ON DUPLICATE KEY UPDATE，PostgreSQL、SQLite 生成 INSERT ... ON CONFLICT DO UPDATE
+func (dal *Test100mDAL) Upsert(ctx context.Context, record *models.Test100mTable) error {
+	return withTraceKey(dal.db.WithContext(ctx), record.Uuid).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "uuid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "email", "nickname"}),
+	}).Create(record).Error
+}
+
+// ReadModifyWrite 在一个事务内依次对 uuids 执行 SELECT ... FOR UPDATE，再用 modify 修改后写回，返回写入的总行数
+// 记录存在时执行 UPDATE，不存在时执行 INSERT（此时锁定读会持有间隙锁）；SQLite 没有行锁，忽略 FOR UPDATE，事务开始时即持有库级写锁
+// opts 用于指定隔离级别，为 nil 时使用数据库默认隔离级别；死锁等错误原样返回，由调用方决定是否重试
+func (dal *Test100mDAL) ReadModifyWrite(ctx context.Context, uuids []string, opts *sql.TxOptions, modify func(record *models.Test100mTable)) (int64, error) {
+	var affected int64
+	err := dal.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, uuid := range uuids {
+			var record models.Test100mTable
+			err := withTraceKey(tx, uuid).Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+				Where("uuid = ?", uuid).First(&record).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				record = models.Test100mTable{Uuid: uuid}
+				modify(&record)
+				result := withTraceKey(tx, uuid).Create(&record)
+				if result.Error != nil {
+					return result.Error
+				}
+				affected += result.RowsAffected
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			modify(&record)
+			result := withTraceKey(tx, uuid).Model(&models.Test100mTable{}).
+				Where("uuid = ?", uuid).
+				Updates(map[string]interface{}{
+					"name":     record.Name,
+					"email":    record.Email,
+					"nickname": record.Nickname,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			affected += result.RowsAffected
+		}
+		return nil
+	}, opts)
+	return affected, err
+}
+
+// Delete 根据 UUID 删除记录，返回影响的行数
+func (dal *Test100mDAL) Delete(ctx context.Context, uuid string) (int64, error) {
+	result := withTraceKey(dal.db.WithContext(ctx), uuid).Where("uuid = ?", uuid).Delete(&models.Test100mTable{})
+	return result.RowsAffected, result.Error
 }
 
-// Delete 根据 UUID 删除记录
-func (dal *Test100mDAL) Delete(uuid string) error {
-	return dal.db.Where("uuid = ?", uuid).Delete(&models.Test100mTable{}).Error
+// CountByUUIDs 返回 uuids 中在表里存在的记录数，用于校验阶段写入或删除的结果；uuids 较多时由调用方分批
+func (dal *Test100mDAL) CountByUUIDs(ctx context.Context, uuids []string) (int64, error) {
+	var count int64
+	err := dal.db.WithContext(ctx).Model(&models.Test100mTable{}).Where("uuid IN ?", uuids).Count(&count).Error
+	return count, err
+}
+
+// EstimateRows 返回表行数的估算值
+func (dal *Test100mDAL) EstimateRows(ctx context.Context) (int64, error) {
+	return estimateTableRows(dal.db.WithContext(ctx), dal.table)
+}
+
+// SampleUUID 返回表中任意一条记录的 UUID，用于以真实主键生成执行计划
+func (dal *Test100mDAL) SampleUUID(ctx context.Context) (string, error) {
+	var record models.Test100mTable
+	if err := dal.db.WithContext(ctx).Select("uuid").Take(&record).Error; err != nil {
+		return "", err
+	}
+	return record.Uuid, nil
+}
+
+// Test100mQueryShapes 返回 Test100mDAL 操作 table 时每个方法的示例调用，用于获取其生成的 SQL 形态
+func Test100mQueryShapes(table, sampleUUID string) []QueryShape {
+	newDAL := func(db *gorm.DB) *Test100mDAL { return NewTest100mDAL(db).WithTable(table) }
+	sample := func() *models.Test100mTable {
+		return &models.Test100mTable{Uuid: sampleUUID, Name: "Name", Email: "email@test.com", Nickname: "Nickname"}
+	}
+	return []QueryShape{
+		{Name: "Test100mDAL.Create", Call: func(db *gorm.DB) error {
+			return newDAL(db).Create(db.Statement.Context, sample())
+		}},
+		{Name: "Test100mDAL.InsertBatch", Call: func(db *gorm.DB) error {
+			return newDAL(db).InsertBatch(db.Statement.Context, []*models.Test100mTable{sample(), sample()})
+		}},
+		{Name: "Test100mDAL.GetByUUID", Call: func(db *gorm.DB) error {
+			_, err := newDAL(db).GetByUUID(db.Statement.Context, sampleUUID)
+			return err
+		}},
+		{Name: "Test100mDAL.Update", Call: func(db *gorm.DB) error {
+			_, err := newDAL(db).Update(db.Statement.Context, sample())
+			return err
+		}},
+		{Name: "Test100mDAL.Upsert", Call: func(db *gorm.DB) error {
+			return newDAL(db).Upsert(db.Statement.Context, sample())
+		}},
+		{Name: "Test100mDAL.ReadModifyWrite", Call: func(db *gorm.DB) error {
+			_, err := newDAL(db).ReadModifyWrite(db.Statement.Context, []string{sampleUUID}, nil, func(*models.Test100mTable) {})
+			return err
+		}},
+		{Name: "Test100mDAL.Delete", Call: func(db *gorm.DB) error {
+			_, err := newDAL(db).Delete(db.Statement.Context, sampleUUID)
+			return err
+		}},
+	}
 }
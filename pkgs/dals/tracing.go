@@ -0,0 +1,139 @@
+package dals
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	// tracingBeforeCallback / tracingAfterCallback 链路追踪回调名称
+	tracingBeforeCallback = "dals:trace_before"
+	tracingAfterCallback  = "dals:trace_after"
+	// tracingSpanKey 在语句实例上保存当前 span 的键
+	tracingSpanKey = "dals:trace_span"
+	// traceKeySetting DAL 通过 db.Set 传递本次操作访问的主键，由追踪回调记录到 span
+	traceKeySetting = "dals:trace_key"
+)
+
+// 自定义的 span 属性
+var (
+	attrStrategy     = attribute.Key("dbbench.strategy")
+	attrTarget       = attribute.Key("dbbench.target")
+	attrKey          = attribute.Key("dbbench.key")
+	attrRowsAffected = attribute.Key("db.response.rows_affected")
+)
+
+// TracingPlugin GORM 插件，为每条 SQL 生成一个 span，记录表名、主键策略、压测目标、主键、SQL、影响行数与错误
+// span 的父级取自 Statement.Context，未传入上下文时每条 SQL 为独立的根 span
+type TracingPlugin struct {
+	tracer   trace.Tracer
+	strategy string
+	target   string
+}
+
+// NewTracingPlugin 创建 TracingPlugin 实例，strategy 为主键策略，如 "uuid"、"crc32_uuid"；
+// target 为压测目标名称，为空时不记录
+func NewTracingPlugin(tracer trace.Tracer, strategy, target string) *TracingPlugin {
+	return &TracingPlugin{tracer: tracer, strategy: strategy, target: target}
+}
+
+// Name 实现 gorm.Plugin
+func (p *TracingPlugin) Name() string {
+	return "dals:tracing"
+}
+
+// Initialize 实现 gorm.Plugin，在各类语句执行前后注册回调
+func (p *TracingPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().Before("gorm:create").Register(tracingBeforeCallback, p.before); err != nil {
+		return err
+	}
+	if err := callback.Create().After("gorm:create").Register(tracingAfterCallback, p.after); err != nil {
+		return err
+	}
+	if err := callback.Query().Before("gorm:query").Register(tracingBeforeCallback, p.before); err != nil {
+		return err
+	}
+	if err := callback.Query().After("gorm:query").Register(tracingAfterCallback, p.after); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register(tracingBeforeCallback, p.before); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register(tracingAfterCallback, p.after); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register(tracingBeforeCallback, p.before); err != nil {
+		return err
+	}
+	if err := callback.Delete().After("gorm:delete").Register(tracingAfterCallback, p.after); err != nil {
+		return err
+	}
+	if err := callback.Raw().Before("gorm:raw").Register(tracingBeforeCallback, p.before); err != nil {
+		return err
+	}
+	if err := callback.Raw().After("gorm:raw").Register(tracingAfterCallback, p.after); err != nil {
+		return err
+	}
+	if err := callback.Row().Before("gorm:row").Register(tracingBeforeCallback, p.before); err != nil {
+		return err
+	}
+	return callback.Row().After("gorm:row").Register(tracingAfterCallback, p.after)
+}
+
+// before 开启 span，此时 SQL 尚未生成，span 名称在 after 中补全
+func (p *TracingPlugin) before(tx *gorm.DB) {
+	if tx.DryRun {
+		return
+	}
+	_, span := p.tracer.Start(tx.Statement.Context, "gorm", trace.WithSpanKind(trace.SpanKindClient))
+	tx.InstanceSet(tracingSpanKey, span)
+}
+
+// after 记录语句信息与执行结果并结束 span
+func (p *TracingPlugin) after(tx *gorm.DB) {
+	value, ok := tx.InstanceGet(tracingSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	sql := tx.Statement.SQL.String()
+	verb := sqlVerb(sql)
+	span.SetName(verb + " " + tx.Statement.Table)
+	span.SetAttributes(
+		semconv.DBSystemNameKey.String(tx.Dialector.Name()),
+		semconv.DBCollectionName(tx.Statement.Table),
+		semconv.DBOperationName(verb),
+		semconv.DBQueryText(sql),
+		attrStrategy.String(p.strategy),
+		attrRowsAffected.Int64(tx.Statement.RowsAffected),
+	)
+	if p.target != "" {
+		span.SetAttributes(attrTarget.String(p.target))
+	}
+	if key, ok := tx.Get(traceKeySetting); ok {
+		if s, ok := key.(string); ok {
+			span.SetAttributes(attrKey.String(s))
+		}
+	}
+	// 未找到记录是查询的正常结果，不视为错误
+	if err := tx.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// withTraceKey 标记本次操作访问的主键，供链路追踪记录；未启用追踪时没有影响
+func withTraceKey(db *gorm.DB, key string) *gorm.DB {
+	return db.Set(traceKeySetting, key)
+}
@@ -0,0 +1,77 @@
+package dals
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/glebarez/go-sqlite"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// MySQL 事务相关错误码
+const (
+	mysqlErrLockWaitTimeout = 1205 // ER_LOCK_WAIT_TIMEOUT 锁等待超时
+	mysqlErrDeadlock        = 1213 // ER_LOCK_DEADLOCK 检测到死锁，事务已被回滚
+)
+
+// IsDeadlock 判断错误是否为 MySQL 死锁（1213）
+func IsDeadlock(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDeadlock
+}
+
+// IsLockWaitTimeout 判断错误是否为 MySQL 锁等待超时（1205）
+func IsLockWaitTimeout(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrLockWaitTimeout
+}
+
+// SQLite 锁相关的主错误码，扩展错误码的低 8 位为主错误码
+const (
+	sqliteBusy   = 5 // SQLITE_BUSY 等待其他连接释放锁超时
+	sqliteLocked = 6 // SQLITE_LOCKED 与同一连接中的其他操作冲突
+)
+
+// IsSQLiteBusy 判断错误是否为 SQLite 的锁冲突（SQLITE_BUSY、SQLITE_LOCKED 及其扩展错误码）
+func IsSQLiteBusy(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code() & 0xff
+	return code == sqliteBusy || code == sqliteLocked
+}
+
+// IsRetryableTxError 判断事务失败后是否可以整体重试（死锁、锁等待超时或 SQLite 锁冲突）
+func IsRetryableTxError(err error) bool {
+	return IsDeadlock(err) || IsLockWaitTimeout(err) || IsSQLiteBusy(err)
+}
+
+// IsNotFound 判断错误是否为按主键查询时记录不存在
+func IsNotFound(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound)
+}
+
+// ParseIsolationLevel 将配置中的隔离级别转换为 sql.IsolationLevel
+// 支持 "READ UNCOMMITTED"、"READ COMMITTED"、"REPEATABLE READ"、"SERIALIZABLE"，
+// 大小写不敏感，空格可写作下划线；空字符串表示使用数据库默认隔离级别
+func ParseIsolationLevel(level string) (sql.IsolationLevel, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(level), "_", " "))
+	switch normalized {
+	case "":
+		return sql.LevelDefault, nil
+	case "READ UNCOMMITTED":
+		return sql.LevelReadUncommitted, nil
+	case "READ COMMITTED":
+		return sql.LevelReadCommitted, nil
+	case "REPEATABLE READ":
+		return sql.LevelRepeatableRead, nil
+	case "SERIALIZABLE":
+		return sql.LevelSerializable, nil
+	default:
+		return sql.LevelDefault, fmt.Errorf("不支持的隔离级别: %s", level)
+	}
+}
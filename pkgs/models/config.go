@@ -1,17 +1,193 @@
 package models
 
+import (
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// 数据库类型
+const (
+	DatabaseMySQL  = "mysql"
+	DatabaseSQLite = "sqlite" // 无需数据库服务，适合在本机或 CI 中运行场景与集成测试
+)
+
 // DatabaseConfig 数据库配置结构体
-// 支持 MySQL 和 PostgreSQL
+// 支持 MySQL 与 SQLite；SQLite 只使用 file 与连接池配置，忽略 host、port、user、database 与 dsn
 type DatabaseConfig struct {
-	Type     string `json:"type" mapstructure:"type"`         // 数据库类型: "mysql" 或 "postgresql"
+	Type     string `json:"type" mapstructure:"type"`         // 数据库类型: "mysql"、"sqlite"
 	Host     string `json:"host" mapstructure:"host"`         // 数据库主机地址
 	Port     int    `json:"port" mapstructure:"port"`         // 数据库端口
 	User     string `json:"user" mapstructure:"user"`         // 数据库用户名
-	Password string `json:"password" mapstructure:"password"` // 数据库密码
+	Password string `json:"password" mapstructure:"password"` // 数据库密码，建议留空并通过环境变量 DBBENCH_DATABASE_PASSWORD、password_file、~/.my.cnf 或 ~/.pgpass 提供
 	Database string `json:"database" mapstructure:"database"` // 数据库名称
+	File     string `json:"file" mapstructure:"file"`         // SQLite 数据库文件路径，":memory:" 为内存库（只使用一个连接）
+
+	PasswordFile string `json:"password_file" mapstructure:"password_file"` // 存放密码的文件路径（如挂载的 secret），password 为空时读取，忽略末尾换行
+
+	Pool PoolConfig `json:"pool" mapstructure:"pool"` // 连接池配置
+	DSN  DSNConfig  `json:"dsn" mapstructure:"dsn"`   // 连接参数
+	Gorm GormConfig `json:"gorm" mapstructure:"gorm"` // GORM 选项
+}
+
+// TargetConfig 一个命名的压测目标，如 "mysql80"、"mysql84"
+type TargetConfig struct {
+	Name     string         `json:"name" mapstructure:"name"`         // 目标名称，写入结果并作为对比时的列名
+	Database DatabaseConfig `json:"database" mapstructure:"database"` // 目标的数据库配置，未出现的字段继承顶层 database
+}
+
+// PoolConfig 连接池配置
+type PoolConfig struct {
+	MaxOpenConns    int           `json:"max_open_conns" mapstructure:"max_open_conns"`         // 最大打开连接数，为 0 时不限制
+	MaxIdleConns    int           `json:"max_idle_conns" mapstructure:"max_idle_conns"`         // 最大空闲连接数，不能超过 max_open_conns
+	ConnMaxLifetime time.Duration `json:"conn_max_lifetime" mapstructure:"conn_max_lifetime"`   // 连接最大生存时间，配置文件中写作 "1h"，为 0 时不限制
+	ConnMaxIdleTime time.Duration `json:"conn_max_idle_time" mapstructure:"conn_max_idle_time"` // 连接最大空闲时间，为 0 时不限制
+}
+
+// DSNConfig 连接字符串中的驱动参数
+type DSNConfig struct {
+	Charset           string        `json:"charset" mapstructure:"charset"`                       // 连接字符集
+	Loc               string        `json:"loc" mapstructure:"loc"`                               // 解析时间使用的时区，如 "Local"、"UTC"、"Asia/Shanghai"
+	Timeout           time.Duration `json:"timeout" mapstructure:"timeout"`                       // 建立连接的超时时间，为 0 时使用系统默认值
+	ReadTimeout       time.Duration `json:"read_timeout" mapstructure:"read_timeout"`             // 读超时，为 0 时不限制
+	WriteTimeout      time.Duration `json:"write_timeout" mapstructure:"write_timeout"`           // 写超时，为 0 时不限制
+	InterpolateParams bool          `json:"interpolate_params" mapstructure:"interpolate_params"` // 是否在客户端插值参数，省去服务端预处理语句的往返
+	TLS               string        `json:"tls" mapstructure:"tls"`                               // TLS 模式: ""（不使用）、"true"、"false"、"skip-verify"、"preferred"
+}
+
+// GormConfig GORM 选项
+type GormConfig struct {
+	PrepareStmt            bool `json:"prepare_stmt" mapstructure:"prepare_stmt"`                         // 是否缓存预处理语句
+	SkipDefaultTransaction bool `json:"skip_default_transaction" mapstructure:"skip_default_transaction"` // 是否跳过单条写操作默认包裹的事务
+	CreateBatchSize        int  `json:"create_batch_size" mapstructure:"create_batch_size"`               // Create 切片时自动分批的大小，为 0 时不分批
+}
+
+// WorkloadConfig 压测负载参数配置
+type WorkloadConfig struct {
+	Seed               uint64  `json:"seed" mapstructure:"seed"`                                 // 生成主键与随机选择的种子，为 0 时每次运行随机选取；多个目标使用同一种子。固定种子每次运行生成相同的主键，各目标的压测表须为空（或只有以其他种子写入的数据），否则写入阶段会因主键重复而失败
+	PhaseOps           int     `json:"phase_ops" mapstructure:"phase_ops"`                       // 各单条操作阶段的操作次数（及准备的数据条数）
+	UpsertConflictRate float64 `json:"upsert_conflict_rate" mapstructure:"upsert_conflict_rate"` // Upsert 阶段中主键已存在的比例，取值 [0, 1]
+	Distribution       string  `json:"distribution" mapstructure:"distribution"`                 // get、update 阶段的主键访问分布，见 Distributions，为空时 get 为 shuffled、update 为 sequential
+	TxKeysPerTx        int     `json:"tx_keys_per_tx" mapstructure:"tx_keys_per_tx"`             // 事务读改写阶段每个事务随机访问的主键数
+	TxIsolation        string  `json:"tx_isolation" mapstructure:"tx_isolation"`                 // 事务隔离级别，如 "REPEATABLE READ"，为空时使用数据库默认值
+	TxMaxRetries       int     `json:"tx_max_retries" mapstructure:"tx_max_retries"`             // 死锁或锁等待超时时事务的最大重试次数
+	TxMissingKeyRate   float64 `json:"tx_missing_key_rate" mapstructure:"tx_missing_key_rate"`   // 事务中访问不存在主键（锁定读后插入）的比例，取值 [0, 1]
+	Concurrency        int     `json:"concurrency" mapstructure:"concurrency"`                   // 各阶段的并发 worker 数，为 0 时使用阶段默认值
+	SweepPhase         string  `json:"sweep_phase" mapstructure:"sweep_phase"`                   // 并发度扫描的阶段名称，为空时不扫描
+	SweepConcurrency   []int   `json:"sweep_concurrency" mapstructure:"sweep_concurrency"`       // 并发度扫描列表，如 [1, 2, 4, 8, 16, 32, 64]
+	BatchTotalRows     int     `json:"batch_total_rows" mapstructure:"batch_total_rows"`         // 批量插入阶段的总行数
+	BatchSize          int     `json:"batch_size" mapstructure:"batch_size"`                     // 批量插入阶段每条 INSERT 的行数
+	BatchSizes         []int   `json:"batch_sizes" mapstructure:"batch_sizes"`                   // 批大小扫描列表，如 [1, 10, 50, 100, 500, 1000, 5000]，为空时不扫描
+
+	SoakOp       string        `json:"soak_op" mapstructure:"soak_op"`             // 长时间运行模式的负载: "create" 或 "mixed"，为空时不运行
+	SoakDuration time.Duration `json:"soak_duration" mapstructure:"soak_duration"` // 长时间运行的总时长，配置文件中写作 "4h"
+	SoakInterval time.Duration `json:"soak_interval" mapstructure:"soak_interval"` // 时间序列的统计间隔，配置文件中写作 "10s"
+
+	OpTimeout    time.Duration `json:"op_timeout" mapstructure:"op_timeout"`       // 单次操作的超时，超时计为失败，为 0 时不限制
+	PhaseTimeout time.Duration `json:"phase_timeout" mapstructure:"phase_timeout"` // 每个阶段（含准备数据）的超时，超时后停止发起新的操作并记录部分结果，为 0 时不限制
+
+	Verify       bool `json:"verify" mapstructure:"verify"`               // 是否在场景的每个阶段后校验行数并回读抽样行，确认写入确实生效
+	VerifySample int  `json:"verify_sample" mapstructure:"verify_sample"` // 校验时回读比对的抽样行数
+}
+
+// Distributions 主键访问分布的可选值：
+// shuffled 每个主键访问一次、顺序随机；sequential 每个主键访问一次、按插入顺序；
+// uniform 有放回地均匀随机访问；zipf 有放回地按 Zipf 分布访问，越早插入的主键越热
+var Distributions = []string{"", "shuffled", "sequential", "uniform", "zipf"}
+
+// OutputConfig 结果输出配置
+type OutputConfig struct {
+	ResultFile     string `json:"result_file" mapstructure:"result_file"`           // 结果 JSON 文件路径，为空时只打印日志
+	TimeSeriesFile string `json:"time_series_file" mapstructure:"time_series_file"` // 长时间运行模式的时间序列文件路径（JSON Lines），每个统计间隔追加一行
+}
+
+// MonitorConfig 压测期间的附加观测配置
+type MonitorConfig struct {
+	ServerStatus bool          `json:"server_status" mapstructure:"server_status"` // 是否在每个阶段前后采集 MySQL GLOBAL STATUS 与 INNODB_METRICS 差值
+	Host         bool          `json:"host" mapstructure:"host"`                   // 是否在每个阶段运行期间从 /proc 采样主机资源（要求数据库运行在本机）
+	HostInterval time.Duration `json:"host_interval" mapstructure:"host_interval"` // 主机资源采样间隔，配置文件中写作 "1s"
+	HostDevices  []string      `json:"host_devices" mapstructure:"host_devices"`   // 需要统计的块设备名，如 ["vda"]，为空时统计所有整盘设备
+
+	Explain        bool `json:"explain" mapstructure:"explain"`                 // 是否对 DAL 的每种 SQL 执行 EXPLAIN FORMAT=JSON 并写入结果
+	ExplainAnalyze bool `json:"explain_analyze" mapstructure:"explain_analyze"` // 是否对只读查询额外执行 EXPLAIN ANALYZE
+
+	StatementDigests bool `json:"statement_digests" mapstructure:"statement_digests"` // 是否在每个阶段前后重置并读取 performance_schema 语句摘要
+	DigestLimit      int  `json:"digest_limit" mapstructure:"digest_limit"`           // 每个阶段记录的语句摘要条数，为 0 时为 20
+
+	TableSize  bool   `json:"table_size" mapstructure:"table_size"`   // 是否在运行结束时记录压测表的存储占用
+	FillFactor bool   `json:"fill_factor" mapstructure:"fill_factor"` // 记录存储占用时是否通过 INNODB_BUFFER_PAGE 估算页填充率
+	Tier       string `json:"tier" mapstructure:"tier"`               // 当前数据量级标签，如 empty、1m、100m，随结果一起记录
+
+	MetricsAddr string `json:"metrics_addr" mapstructure:"metrics_addr"` // Prometheus 指标监听地址，如 ":9100"，为空时不启动
+	SlowestOps  int    `json:"slowest_ops" mapstructure:"slowest_ops"`   // 每个阶段在结果中保留的最慢操作数（含主键、开始时间、worker 编号与错误），为 0 时不记录
+
+	Progress         bool          `json:"progress" mapstructure:"progress"`                   // 是否在阶段运行期间展示实时进度：终端中原地刷新，否则定期输出日志行
+	ProgressInterval time.Duration `json:"progress_interval" mapstructure:"progress_interval"` // 非终端模式下输出进度日志的间隔，配置文件中写作 "10s"
+
+	TraceExporter    string  `json:"trace_exporter" mapstructure:"trace_exporter"`         // 链路追踪导出器: "otlp" 或 "file"，为空时不追踪
+	TraceEndpoint    string  `json:"trace_endpoint" mapstructure:"trace_endpoint"`         // OTLP/HTTP 收集器地址，如 "localhost:4318"，为空时使用 OTEL_EXPORTER_OTLP_ENDPOINT 或默认地址
+	TraceFile        string  `json:"trace_file" mapstructure:"trace_file"`                 // file 导出器的输出文件（JSON Lines，每行一个 span）
+	TraceSampleRatio float64 `json:"trace_sample_ratio" mapstructure:"trace_sample_ratio"` // 链路采样比例，取值 (0, 1]，为 0 时全部采样
+}
+
+// LogConfig 日志配置
+type LogConfig struct {
+	Level  string `json:"level" mapstructure:"level"`   // 日志级别: "debug"、"info"、"warn"、"error"，为空时为 info
+	Format string `json:"format" mapstructure:"format"` // 日志格式: "text" 或 "json"，为空时为 text
+	Output string `json:"output" mapstructure:"output"` // 日志输出: "stdout"、"stderr" 或文件路径，为空时为 stdout
+
+	GormLevel     string        `json:"gorm_level" mapstructure:"gorm_level"`           // GORM 日志级别: "silent"、"error"、"warn"、"info"，为空时为 warn
+	SlowThreshold time.Duration `json:"slow_threshold" mapstructure:"slow_threshold"`   // 慢查询阈值，配置文件中写作 "200ms"，为 0 时为 2 秒
+	SlowQueryMode string        `json:"slow_query_mode" mapstructure:"slow_query_mode"` // 慢查询处理方式: "log" 逐条打印，"count" 只计数并写入阶段结果，"off" 不处理，为空时为 log
 }
 
 // Config 应用配置结构体
 type Config struct {
 	Database DatabaseConfig `json:"database" mapstructure:"database"` // 数据库配置
+	Targets  []TargetConfig `json:"targets" mapstructure:"-"`         // 压测目标列表，run 依次对每个目标执行相同的场景；为空时只使用 database
+	Workload WorkloadConfig `json:"workload" mapstructure:"workload"` // 压测负载配置
+	Output   OutputConfig   `json:"output" mapstructure:"output"`     // 结果输出配置
+	Monitor  MonitorConfig  `json:"monitor" mapstructure:"monitor"`   // 附加观测配置
+	Log      LogConfig      `json:"log" mapstructure:"log"`           // 日志配置
+}
+
+// RunTargets 返回 run 依次执行的目标，未配置 targets 时为顶层 database 对应的一个未命名目标
+func (c *Config) RunTargets() []TargetConfig {
+	if len(c.Targets) > 0 {
+		return c.Targets
+	}
+	return []TargetConfig{{Database: c.Database}}
+}
+
+// redactedPassword 替换日志与输出中密码的占位符
+const redactedPassword = "******"
+
+// Redacted 返回隐去密码的配置副本，用于打印或写入文件
+func (c *Config) Redacted() *Config {
+	copied := *c
+	if copied.Database.Password != "" {
+		copied.Database.Password = redactedPassword
+	}
+	copied.Targets = slices.Clone(c.Targets)
+	for i := range copied.Targets {
+		if copied.Targets[i].Database.Password != "" {
+			copied.Targets[i].Database.Password = redactedPassword
+		}
+	}
+	return &copied
+}
+
+// LogValue 实现 slog.LogValuer，记录连接信息时不输出密码
+func (d DatabaseConfig) LogValue() slog.Value {
+	if d.Type == DatabaseSQLite {
+		return slog.GroupValue(slog.String("type", d.Type), slog.String("file", d.File))
+	}
+	return slog.GroupValue(
+		slog.String("type", d.Type),
+		slog.String("host", d.Host),
+		slog.Int("port", d.Port),
+		slog.String("user", d.User),
+		slog.String("database", d.Database),
+		slog.Bool("password_set", d.Password != ""),
+	)
 }
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// DefaultConfig 返回所有配置项的默认值
+// 读取配置文件时以此为基础，文件中未出现的字段保留默认值；dbbench config example 据此生成示例配置
+func DefaultConfig() *Config {
+	return &Config{
+		Database: DatabaseConfig{
+			Type:     DatabaseMySQL,
+			Host:     "localhost",
+			Port:     3306,
+			User:     "root",
+			Database: "test_100m_db",
+			Pool: PoolConfig{
+				MaxOpenConns:    100,
+				MaxIdleConns:    10,
+				ConnMaxLifetime: time.Hour,
+			},
+			DSN: DSNConfig{
+				Charset: "utf8mb4",
+				Loc:     "Local",
+				Timeout: 10 * time.Second,
+			},
+		},
+		Workload: WorkloadConfig{
+			PhaseOps:           10000,
+			UpsertConflictRate: 0.5,
+			TxKeysPerTx:        5,
+			TxMaxRetries:       3,
+			TxMissingKeyRate:   0.1,
+			Concurrency:        80,
+			SweepConcurrency:   []int{1, 2, 4, 8, 16, 32, 64, 128},
+			BatchTotalRows:     10000,
+			BatchSize:          100,
+			SoakDuration:       time.Hour,
+			SoakInterval:       10 * time.Second,
+			OpTimeout:          30 * time.Second,
+			VerifySample:       100,
+		},
+		Output: OutputConfig{
+			ResultFile:     "results/result.json",
+			TimeSeriesFile: "results/soak.jsonl",
+		},
+		Monitor: MonitorConfig{
+			HostInterval:     time.Second,
+			DigestLimit:      20,
+			SlowestOps:       20,
+			ProgressInterval: 10 * time.Second,
+			TraceFile:        "results/traces.jsonl",
+			TraceSampleRatio: 1,
+		},
+		Log: LogConfig{
+			Level:         "info",
+			Format:        "text",
+			Output:        "stdout",
+			GormLevel:     "warn",
+			SlowThreshold: 2 * time.Second,
+			SlowQueryMode: "log",
+		},
+	}
+}
@@ -0,0 +1,198 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// databaseNamePattern 库名会直接拼入 CREATE DATABASE，只允许不需要转义的字符
+var databaseNamePattern = regexp.MustCompile(`^[A-Za-z0-9_$]+$`)
+
+// configErrors 收集配置校验中发现的问题，每条以字段路径开头
+type configErrors []error
+
+func (e *configErrors) addf(path, format string, args ...any) {
+	*e = append(*e, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+// oneOf 检查 value 是否为允许的取值之一，比较时忽略大小写；allowed 含空字符串时表示可以留空
+func (e *configErrors) oneOf(path, value string, allowed ...string) {
+	if slices.ContainsFunc(allowed, func(a string) bool { return strings.EqualFold(a, value) }) {
+		return
+	}
+	choices := strings.Join(slices.DeleteFunc(slices.Clone(allowed), func(a string) bool { return a == "" }), "、")
+	if slices.Contains(allowed, "") {
+		choices += "，或留空"
+	}
+	e.addf(path, "不支持的取值 %q（可选 %s）", value, choices)
+}
+
+func (e *configErrors) nonNegative(path string, value int) {
+	if value < 0 {
+		e.addf(path, "不能为负数，当前为 %d", value)
+	}
+}
+
+func (e *configErrors) nonNegativeDuration(path string, value time.Duration) {
+	if value < 0 {
+		e.addf(path, "不能为负数，当前为 %s", value)
+	}
+}
+
+func (e *configErrors) ratio(path string, value float64) {
+	if value < 0 || value > 1 {
+		e.addf(path, "取值应在 [0, 1] 之间，当前为 %g", value)
+	}
+}
+
+// Validate 检查配置中的所有字段，一次性返回全部问题而不是遇到第一个就停止
+// 返回的错误由 errors.Join 合并，每行形如 "database.pool.max_idle_conns: ..."；应在连接数据库之前调用
+func (c *Config) Validate() error {
+	var errs configErrors
+	c.Database.validate(&errs, "database")
+	names := make(map[string]bool)
+	for i := range c.Targets {
+		t := &c.Targets[i]
+		path := fmt.Sprintf("targets[%d]", i)
+		if t.Name == "" {
+			errs.addf(path+".name", "不能为空")
+		} else if names[t.Name] {
+			errs.addf(path+".name", "与前面的目标重名: %q", t.Name)
+		}
+		names[t.Name] = true
+		t.Database.validate(&errs, path+".database")
+	}
+	c.Workload.validate(&errs)
+	c.Monitor.validate(&errs)
+	c.Log.validate(&errs)
+	return errors.Join(errs...)
+}
+
+func (d *DatabaseConfig) validate(errs *configErrors, path string) {
+	errs.oneOf(path+".type", d.Type, DatabaseMySQL, DatabaseSQLite)
+	if d.Type == DatabaseSQLite {
+		if d.File == "" {
+			errs.addf(path+".file", "type 为 sqlite 时不能为空，内存库写作 \":memory:\"")
+		}
+		d.validatePool(errs, path)
+		return
+	}
+	if d.Host == "" {
+		errs.addf(path+".host", "不能为空")
+	}
+	if d.Port < 1 || d.Port > 65535 {
+		errs.addf(path+".port", "取值应在 [1, 65535] 之间，当前为 %d", d.Port)
+	}
+	if d.User == "" {
+		errs.addf(path+".user", "不能为空")
+	}
+	if !databaseNamePattern.MatchString(d.Database) {
+		errs.addf(path+".database", "库名 %q 只能包含字母、数字、下划线与 $", d.Database)
+	}
+	d.validatePool(errs, path)
+
+	dsn := &d.DSN
+	if dsn.Charset == "" {
+		errs.addf(path+".dsn.charset", "不能为空")
+	}
+	if _, err := time.LoadLocation(dsn.Loc); dsn.Loc == "" || err != nil {
+		errs.addf(path+".dsn.loc", "无效的时区 %q", dsn.Loc)
+	}
+	errs.nonNegativeDuration(path+".dsn.timeout", dsn.Timeout)
+	errs.nonNegativeDuration(path+".dsn.read_timeout", dsn.ReadTimeout)
+	errs.nonNegativeDuration(path+".dsn.write_timeout", dsn.WriteTimeout)
+	errs.oneOf(path+".dsn.tls", dsn.TLS, "", "true", "false", "skip-verify", "preferred")
+}
+
+// validatePool 检查连接池与 GORM 选项，两种数据库类型共用
+func (d *DatabaseConfig) validatePool(errs *configErrors, path string) {
+	p := &d.Pool
+	errs.nonNegative(path+".pool.max_open_conns", p.MaxOpenConns)
+	errs.nonNegative(path+".pool.max_idle_conns", p.MaxIdleConns)
+	if p.MaxOpenConns > 0 && p.MaxIdleConns > p.MaxOpenConns {
+		errs.addf(path+".pool.max_idle_conns", "不能超过 max_open_conns（%d），当前为 %d", p.MaxOpenConns, p.MaxIdleConns)
+	}
+	errs.nonNegativeDuration(path+".pool.conn_max_lifetime", p.ConnMaxLifetime)
+	errs.nonNegativeDuration(path+".pool.conn_max_idle_time", p.ConnMaxIdleTime)
+	errs.nonNegative(path+".gorm.create_batch_size", d.Gorm.CreateBatchSize)
+}
+
+func (w *WorkloadConfig) validate(errs *configErrors) {
+	if w.PhaseOps < 1 {
+		errs.addf("workload.phase_ops", "至少为 1，当前为 %d", w.PhaseOps)
+	}
+	errs.ratio("workload.upsert_conflict_rate", w.UpsertConflictRate)
+	errs.oneOf("workload.distribution", w.Distribution, Distributions...)
+	if w.TxKeysPerTx < 1 {
+		errs.addf("workload.tx_keys_per_tx", "至少为 1，当前为 %d", w.TxKeysPerTx)
+	} else if w.PhaseOps >= 1 && w.TxKeysPerTx > w.PhaseOps {
+		errs.addf("workload.tx_keys_per_tx", "不能超过 phase_ops（%d），当前为 %d", w.PhaseOps, w.TxKeysPerTx)
+	}
+	isolation := strings.ReplaceAll(strings.TrimSpace(w.TxIsolation), "_", " ")
+	errs.oneOf("workload.tx_isolation", isolation, "", "READ UNCOMMITTED", "READ COMMITTED", "REPEATABLE READ", "SERIALIZABLE")
+	errs.nonNegative("workload.tx_max_retries", w.TxMaxRetries)
+	errs.ratio("workload.tx_missing_key_rate", w.TxMissingKeyRate)
+	errs.nonNegative("workload.concurrency", w.Concurrency)
+
+	if w.SweepPhase != "" && len(w.SweepConcurrency) == 0 {
+		errs.addf("workload.sweep_concurrency", "设置了 sweep_phase 时不能为空")
+	}
+	for i, n := range w.SweepConcurrency {
+		if n < 1 {
+			errs.addf(fmt.Sprintf("workload.sweep_concurrency[%d]", i), "至少为 1，当前为 %d", n)
+		}
+	}
+
+	errs.nonNegative("workload.batch_total_rows", w.BatchTotalRows)
+	errs.nonNegative("workload.batch_size", w.BatchSize)
+	if len(w.BatchSizes) > 0 && w.BatchTotalRows < 1 {
+		errs.addf("workload.batch_total_rows", "设置了 batch_sizes 时至少为 1，当前为 %d", w.BatchTotalRows)
+	}
+	for i, n := range w.BatchSizes {
+		if n < 1 {
+			errs.addf(fmt.Sprintf("workload.batch_sizes[%d]", i), "至少为 1，当前为 %d", n)
+		}
+	}
+
+	errs.oneOf("workload.soak_op", w.SoakOp, "", "create", "mixed")
+	errs.nonNegativeDuration("workload.op_timeout", w.OpTimeout)
+	errs.nonNegativeDuration("workload.phase_timeout", w.PhaseTimeout)
+	errs.nonNegative("workload.verify_sample", w.VerifySample)
+	errs.nonNegativeDuration("workload.soak_duration", w.SoakDuration)
+	errs.nonNegativeDuration("workload.soak_interval", w.SoakInterval)
+	if w.SoakOp != "" {
+		if w.SoakDuration <= 0 {
+			errs.addf("workload.soak_duration", "设置了 soak_op 时必须大于 0")
+		}
+		if w.SoakInterval <= 0 {
+			errs.addf("workload.soak_interval", "设置了 soak_op 时必须大于 0")
+		} else if w.SoakDuration > 0 && w.SoakInterval > w.SoakDuration {
+			errs.addf("workload.soak_interval", "不能超过 soak_duration（%s），当前为 %s", w.SoakDuration, w.SoakInterval)
+		}
+	}
+}
+
+func (m *MonitorConfig) validate(errs *configErrors) {
+	errs.nonNegativeDuration("monitor.host_interval", m.HostInterval)
+	errs.nonNegative("monitor.digest_limit", m.DigestLimit)
+	errs.nonNegative("monitor.slowest_ops", m.SlowestOps)
+	errs.nonNegativeDuration("monitor.progress_interval", m.ProgressInterval)
+
+	errs.oneOf("monitor.trace_exporter", m.TraceExporter, "", "otlp", "file")
+	if strings.EqualFold(m.TraceExporter, "file") && m.TraceFile == "" {
+		errs.addf("monitor.trace_file", "trace_exporter 为 file 时不能为空")
+	}
+	errs.ratio("monitor.trace_sample_ratio", m.TraceSampleRatio)
+}
+
+func (l *LogConfig) validate(errs *configErrors) {
+	errs.oneOf("log.level", l.Level, "", "debug", "info", "warn", "error")
+	errs.oneOf("log.format", l.Format, "", "text", "json")
+	errs.oneOf("log.gorm_level", l.GormLevel, "", "silent", "error", "warn", "info")
+	errs.nonNegativeDuration("log.slow_threshold", l.SlowThreshold)
+	errs.oneOf("log.slow_query_mode", l.SlowQueryMode, "", "log", "count", "off")
+}
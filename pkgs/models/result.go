@@ -0,0 +1,291 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LatencySummary 操作延迟分布摘要，单位为毫秒
+type LatencySummary struct {
+	AvgMs float64 `json:"avg_ms"` // 平均延迟
+	P50Ms float64 `json:"p50_ms"` // 50 分位延迟
+	P90Ms float64 `json:"p90_ms"` // 90 分位延迟
+	P99Ms float64 `json:"p99_ms"` // 99 分位延迟
+	MaxMs float64 `json:"max_ms"` // 最大延迟
+}
+
+// MetricSummary 采样指标在一个阶段内的平均值与峰值
+type MetricSummary struct {
+	Avg  float64 `json:"avg"`  // 平均值
+	Peak float64 `json:"peak"` // 峰值
+}
+
+// HostStats 阶段运行期间从 /proc 采样得到的主机资源占用
+type HostStats struct {
+	Samples          int           `json:"samples"`            // 采样区间数
+	CPUPercent       MetricSummary `json:"cpu_percent"`        // 主机 CPU 使用率（%）
+	MemUsedMB        MetricSummary `json:"mem_used_mb"`        // 主机已用内存（MemTotal - MemAvailable，MB）
+	DiskIOPS         MetricSummary `json:"disk_iops"`          // 磁盘每秒完成的读写请求数
+	DiskReadMBps     MetricSummary `json:"disk_read_mbps"`     // 磁盘读带宽（MB/s）
+	DiskWriteMBps    MetricSummary `json:"disk_write_mbps"`    // 磁盘写带宽（MB/s）
+	DiskAwaitMs      MetricSummary `json:"disk_await_ms"`      // 磁盘请求平均等待时间（毫秒）
+	ClientCPUPercent MetricSummary `json:"client_cpu_percent"` // 压测进程自身的 CPU 使用率（%，多核时可超过 100）
+	ClientRSSMB      MetricSummary `json:"client_rss_mb"`      // 压测进程自身的常驻内存（MB）
+}
+
+// StatementDigest performance_schema 中一类语句（按摘要归并）在阶段内的服务端统计，耗时单位为毫秒
+type StatementDigest struct {
+	Digest         string  `json:"digest"`           // 语句摘要哈希
+	DigestText     string  `json:"digest_text"`      // 归一化后的语句文本
+	Count          int64   `json:"count"`            // 执行次数
+	TotalLatencyMs float64 `json:"total_latency_ms"` // 服务端总耗时
+	AvgLatencyMs   float64 `json:"avg_latency_ms"`   // 服务端平均耗时
+	MaxLatencyMs   float64 `json:"max_latency_ms"`   // 服务端最大耗时
+	LockTimeMs     float64 `json:"lock_time_ms"`     // 等待表锁的总时间
+	RowsExamined   int64   `json:"rows_examined"`    // 扫描的总行数
+	RowsSent       int64   `json:"rows_sent"`        // 返回的总行数
+	RowsAffected   int64   `json:"rows_affected"`    // 影响的总行数
+}
+
+// PhaseResult 单个压测阶段的结果
+type PhaseResult struct {
+	Phase       string           `json:"phase"`                  // 阶段名称，如 create、get
+	Concurrency int              `json:"concurrency"`            // 并发 worker 数
+	Ops         int64            `json:"ops"`                    // 执行的操作次数
+	Errors      int64            `json:"errors"`                 // 失败的操作次数
+	ElapsedMs   int64            `json:"elapsed_ms"`             // 计时部分的总耗时（毫秒）
+	OpsPerSec   float64          `json:"ops_per_sec"`            // 吞吐（次/秒）
+	Latency     LatencySummary   `json:"latency"`                // 单次操作的延迟分布
+	Counters    map[string]int64 `json:"counters,omitempty"`     // 阶段特有的计数器，如事务重试次数
+	BatchSize   int              `json:"batch_size,omitempty"`   // 批量插入阶段每条 INSERT 的行数
+	RowsPerSec  float64          `json:"rows_per_sec,omitempty"` // 批量插入阶段按行计算的吞吐（行/秒）
+	Incomplete  bool             `json:"incomplete,omitempty"`   // 阶段因中断或超时未执行完，Ops 为实际完成的操作次数
+
+	// RowsMismatch 影响行数与预期不符的操作次数（如更新或删除没有匹配到记录），已计入 Errors
+	RowsMismatch int64         `json:"rows_mismatch,omitempty"`
+	Verification *Verification `json:"verification,omitempty"` // 阶段结束后的数据校验结果，未开启校验时为空
+
+	Host *HostStats `json:"host,omitempty"` // 阶段运行期间的主机资源占用
+
+	Digests []*StatementDigest `json:"digests,omitempty"` // 阶段内按服务端总耗时排序的语句摘要
+
+	// ServerStatus 阶段前后 MySQL GLOBAL STATUS 与 INNODB_METRICS（键名带 "innodb_metrics." 前缀）计数器的差值
+	ServerStatus map[string]int64 `json:"server_status,omitempty"`
+
+	SlowestOps []*SlowOp `json:"slowest_ops,omitempty"` // 阶段内耗时最长的若干次操作，按延迟从高到低排列
+}
+
+// Verification 阶段结束后的数据校验结果：统计阶段成功的操作涉及的主键当前是否存在，并回读抽样行比对写入的值
+type Verification struct {
+	Rows       int64    `json:"rows"`               // 应存在（删除阶段为应已删除）的主键数
+	Found      int64    `json:"found"`              // 其中当前在表中存在的主键数
+	Sampled    int      `json:"sampled"`            // 回读比对的抽样行数，阶段写入的值不确定时为 0
+	Mismatched int      `json:"mismatched"`         // 抽样行中不存在或值与写入不一致的行数
+	Examples   []string `json:"examples,omitempty"` // 若干不一致的示例
+	Passed     bool     `json:"passed"`             // 行数与抽样行均符合预期
+}
+
+// String 返回便于日志输出的单行摘要
+func (v *Verification) String() string {
+	status := "通过"
+	if !v.Passed {
+		status = "未通过"
+	}
+	return fmt.Sprintf("校验%s: 主键 %d 个，存在 %d 个，抽样回读 %d 行，不一致 %d 行", status, v.Rows, v.Found, v.Sampled, v.Mismatched)
+}
+
+// SlowOp 一次慢操作的详细信息，用于判断长尾延迟是集中在某段时间还是某些主键上
+type SlowOp struct {
+	Keys      []string  `json:"keys,omitempty"`  // 操作访问的主键，批量插入不记录
+	StartedAt time.Time `json:"started_at"`      // 操作开始时间
+	LatencyMs float64   `json:"latency_ms"`      // 操作耗时（毫秒）
+	Worker    int       `json:"worker"`          // 执行操作的 worker 编号
+	Index     int       `json:"index"`           // 操作在阶段内的序号
+	Error     string    `json:"error,omitempty"` // 操作失败时的错误信息
+}
+
+// BufferPoolHitRatio 根据服务端状态差值计算本阶段的缓冲池命中率，无数据时 ok 为 false
+func (r *PhaseResult) BufferPoolHitRatio() (ratio float64, ok bool) {
+	requests := r.ServerStatus["Innodb_buffer_pool_read_requests"]
+	if requests <= 0 {
+		return 0, false
+	}
+	return 1 - float64(r.ServerStatus["Innodb_buffer_pool_reads"])/float64(requests), true
+}
+
+// ServerTimeShare 返回语句摘要中的服务端总耗时占客户端观测到的操作总耗时的比例，
+// 比例越低说明客户端与 GORM 的开销越大；无摘要数据时 ok 为 false
+func (r *PhaseResult) ServerTimeShare() (share float64, ok bool) {
+	clientMs := r.Latency.AvgMs * float64(r.Ops)
+	if len(r.Digests) == 0 || clientMs <= 0 {
+		return 0, false
+	}
+	var serverMs float64
+	for _, digest := range r.Digests {
+		serverMs += digest.TotalLatencyMs
+	}
+	return serverMs / clientMs, true
+}
+
+// LogValue 实现 slog.LogValuer，结构化日志中以分组形式输出阶段的主要指标
+func (r *PhaseResult) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("phase", r.Phase),
+		slog.Int("concurrency", r.Concurrency),
+		slog.Int64("ops", r.Ops),
+		slog.Int64("errors", r.Errors),
+		slog.Int64("elapsed_ms", r.ElapsedMs),
+		slog.Float64("ops_per_sec", r.OpsPerSec),
+		slog.Float64("p50_ms", r.Latency.P50Ms),
+		slog.Float64("p99_ms", r.Latency.P99Ms),
+		slog.Float64("max_ms", r.Latency.MaxMs),
+	}
+	if r.BatchSize > 0 {
+		attrs = append(attrs, slog.Int("batch_size", r.BatchSize), slog.Float64("rows_per_sec", r.RowsPerSec))
+	}
+	if r.Incomplete {
+		attrs = append(attrs, slog.Bool("incomplete", true))
+	}
+	if r.RowsMismatch > 0 {
+		attrs = append(attrs, slog.Int64("rows_mismatch", r.RowsMismatch))
+	}
+	if r.Verification != nil {
+		attrs = append(attrs, slog.Bool("verified", r.Verification.Passed))
+	}
+	names := make([]string, 0, len(r.Counters))
+	for name := range r.Counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		attrs = append(attrs, slog.Int64(name, r.Counters[name]))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// String 返回便于日志输出的单行摘要
+func (r *PhaseResult) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s 完成（并发 %d），耗时: %d ms，吞吐: %.1f ops/s，延迟 avg/p50/p90/p99/max: %.2f/%.2f/%.2f/%.2f/%.2f ms",
+		r.Phase, r.Concurrency, r.ElapsedMs, r.OpsPerSec,
+		r.Latency.AvgMs, r.Latency.P50Ms, r.Latency.P90Ms, r.Latency.P99Ms, r.Latency.MaxMs)
+	if r.BatchSize > 0 {
+		fmt.Fprintf(&b, "，批大小: %d，行吞吐: %.1f rows/s", r.BatchSize, r.RowsPerSec)
+	}
+	if r.Errors > 0 {
+		fmt.Fprintf(&b, "，失败: %d", r.Errors)
+	}
+	if r.RowsMismatch > 0 {
+		fmt.Fprintf(&b, "（其中影响行数不符: %d）", r.RowsMismatch)
+	}
+	if r.Incomplete {
+		fmt.Fprintf(&b, "，未完成（中断时已完成 %d 次操作）", r.Ops)
+	}
+
+	if ratio, ok := r.BufferPoolHitRatio(); ok {
+		fmt.Fprintf(&b, "，缓冲池命中率: %.4f，磁盘读页: %d，页分裂: %d，redo 写入: %d 字节，行锁等待: %d",
+			ratio, r.ServerStatus["Innodb_buffer_pool_reads"], r.ServerStatus["innodb_metrics.index_page_splits"],
+			r.ServerStatus["Innodb_os_log_written"], r.ServerStatus["Innodb_row_lock_waits"])
+	}
+
+	if r.Host != nil {
+		fmt.Fprintf(&b, "，CPU avg/peak: %.1f/%.1f%%，IOPS avg/peak: %.0f/%.0f，await: %.2f ms",
+			r.Host.CPUPercent.Avg, r.Host.CPUPercent.Peak, r.Host.DiskIOPS.Avg, r.Host.DiskIOPS.Peak, r.Host.DiskAwaitMs.Avg)
+	}
+
+	if share, ok := r.ServerTimeShare(); ok {
+		fmt.Fprintf(&b, "，服务端耗时占比: %.1f%%", share*100)
+	}
+
+	names := make([]string, 0, len(r.Counters))
+	for name := range r.Counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "，%s: %d", name, r.Counters[name])
+	}
+	return b.String()
+}
+
+// SweepResult 并发度扫描结果，同一阶段在不同并发度下各执行一次
+type SweepResult struct {
+	Phase           string         `json:"phase"`            // 被扫描的阶段名称
+	Points          []*PhaseResult `json:"points"`           // 各并发度下的阶段结果，按并发度升序
+	KneeConcurrency int            `json:"knee_concurrency"` // 吞吐增长趋于饱和的并发度（拐点）
+}
+
+// BatchSweepResult 批大小扫描结果，总行数不变，依次使用不同批大小批量插入
+type BatchSweepResult struct {
+	TotalRows int            `json:"total_rows"` // 每个批大小下插入的总行数
+	Points    []*PhaseResult `json:"points"`     // 各批大小下的阶段结果，按批大小升序
+}
+
+// SoakPoint 长时间运行模式下一个统计间隔的时间序列数据点
+type SoakPoint struct {
+	Time       time.Time      `json:"time"`        // 间隔结束时间
+	ElapsedSec float64        `json:"elapsed_sec"` // 自运行开始经过的秒数
+	Ops        int64          `json:"ops"`         // 本间隔内完成的操作数
+	Errors     int64          `json:"errors"`      // 本间隔内失败的操作数
+	OpsPerSec  float64        `json:"ops_per_sec"` // 本间隔内的吞吐（次/秒）
+	Latency    LatencySummary `json:"latency"`     // 本间隔内的延迟分布
+	RowCount   int64          `json:"row_count"`   // 当前表行数（运行开始时的估算值加上已插入的行数）
+}
+
+// QueryPlan DAL 发出的一种 SQL 的执行计划
+type QueryPlan struct {
+	Name       string          `json:"name"`              // SQL 来源，如 "Test100mDAL.GetByUUID"，一次调用发出多条 SQL 时带 "#序号"
+	SQL        string          `json:"sql"`               // 带占位符的 SQL
+	AccessType string          `json:"access_type"`       // 访问类型，如 const、eq_ref、range、ALL
+	Key        string          `json:"key"`               // 使用的索引，如 PRIMARY
+	Checked    bool            `json:"checked"`           // 是否检查了访问路径（INSERT 不检查）
+	Regression bool            `json:"regression"`        // 访问路径不符合主键访问的预期
+	Plan       json.RawMessage `json:"plan"`              // EXPLAIN FORMAT=JSON 的原始结果
+	Analyze    string          `json:"analyze,omitempty"` // EXPLAIN ANALYZE 的结果
+}
+
+// TableSize 一张压测表的存储占用
+type TableSize struct {
+	Tier         string  `json:"tier,omitempty"`         // 数据量级标签，如 empty、1m、100m
+	Schema       string  `json:"schema"`                 // 所在数据库
+	Table        string  `json:"table"`                  // 表名
+	RowsEstimate int64   `json:"rows_estimate"`          // 行数估算值（TABLE_ROWS）
+	AvgRowLength int64   `json:"avg_row_length"`         // 平均行长（字节）
+	DataLength   int64   `json:"data_length"`            // 聚簇索引（数据）大小（字节）
+	IndexLength  int64   `json:"index_length"`           // 二级索引大小（字节）
+	DataFree     int64   `json:"data_free"`              // 已分配未使用的空间（字节）
+	BytesPerRow  float64 `json:"bytes_per_row"`          // 每行占用的数据与索引空间（字节）
+	CachedPages  int64   `json:"cached_pages,omitempty"` // 缓冲池中该表聚簇索引的页数
+	FillFactor   float64 `json:"fill_factor,omitempty"`  // 已缓存聚簇索引页的平均填充率
+}
+
+// RunResult 一次压测运行（一个目标）的完整结果
+type RunResult struct {
+	Target      string              `json:"target,omitempty"`       // 压测目标名称，未配置 targets 时为空
+	Scenario    string              `json:"scenario,omitempty"`     // 压测场景名称
+	Strategy    string              `json:"strategy"`               // 主键策略，如 uuid、crc32_uuid
+	Seed        uint64              `json:"seed"`                   // 生成主键与随机选择的种子，相同种子可复现相同的主键序列
+	StartedAt   time.Time           `json:"started_at"`             // 运行开始时间
+	Phases      []*PhaseResult      `json:"phases,omitempty"`       // 依次执行的各阶段结果
+	Sweeps      []*SweepResult      `json:"sweeps,omitempty"`       // 并发度扫描结果
+	BatchSweeps []*BatchSweepResult `json:"batch_sweeps,omitempty"` // 批大小扫描结果
+	QueryPlans  []*QueryPlan        `json:"query_plans,omitempty"`  // DAL 各 SQL 形态的执行计划
+	Tables      []*TableSize        `json:"tables,omitempty"`       // 运行结束时压测表的存储占用
+	Incomplete  bool                `json:"incomplete,omitempty"`   // 运行被中断或失败，只包含此前已完成（或部分完成）的阶段
+	Error       string              `json:"error,omitempty"`        // 运行失败时的错误信息，结果中只包含失败之前完成的部分
+}
+
+// RunSet 一次 run 对多个目标依次执行同一场景的合并结果，各目标使用相同的种子
+type RunSet struct {
+	Scenario  string       `json:"scenario"`   // 压测场景，如 crud
+	Strategy  string       `json:"strategy"`   // 主键策略
+	Seed      uint64       `json:"seed"`       // 各目标共用的种子
+	StartedAt time.Time    `json:"started_at"` // 第一个目标开始的时间
+	Runs      []*RunResult `json:"runs"`       // 按配置顺序排列的各目标结果
+
+	Incomplete bool `json:"incomplete,omitempty"` // 运行被中断（之后的目标没有执行）或有目标失败
+}
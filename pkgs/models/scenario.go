@@ -0,0 +1,194 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Scenario 声明式的压测场景，可写成 YAML 或 JSON 文件，run 无需重新编译即可执行
+// 例如在 ddl 中为表加上新的索引，即可对比该索引对各阶段吞吐与延迟的影响
+type Scenario struct {
+	Name        string `json:"name" mapstructure:"name"`               // 场景名称，写入结果
+	Description string `json:"description" mapstructure:"description"` // 场景说明
+	Strategy    string `json:"strategy" mapstructure:"strategy"`       // 主键策略（决定模型与 DAL）: uuid、crc32_uuid，为空时为 uuid
+
+	// Table 压测表名，为空时取 ddl 中的表名，ddl 也为空时为主键策略的默认表
+	Table string `json:"table" mapstructure:"table"`
+	// DDL 压测表的建表语句，列须与主键策略的模型一致；run 开始前表不存在时执行，为空时不建表
+	DDL string `json:"ddl" mapstructure:"ddl"`
+	// DDLSQLite 在 SQLite 目标上代替 ddl 执行的建表语句，须创建同名的表，其后可追加 CREATE INDEX；为空时 SQLite 目标也执行 ddl
+	DDLSQLite string `json:"ddl_sqlite" mapstructure:"ddl_sqlite"`
+
+	Preload *PreloadSpec `json:"preload" mapstructure:"preload"` // 执行阶段之前把表预填充到的数据量级，为空时不预填充
+	Phases  []PhaseSpec  `json:"phases" mapstructure:"phases"`   // 依次执行的阶段
+}
+
+// PreloadSpec 场景的预填充数据量级
+type PreloadSpec struct {
+	Rows        int    `json:"rows" mapstructure:"rows"`               // 表的目标行数，按行数估算值补齐差额，已达到时跳过
+	BatchSize   int    `json:"batch_size" mapstructure:"batch_size"`   // 每条 INSERT 的行数，为 0 时为 1000
+	Concurrency int    `json:"concurrency" mapstructure:"concurrency"` // 并发 worker 数，为 0 时为 16
+	Tier        string `json:"tier" mapstructure:"tier"`               // 数据量级标签，如 1m、100m，随存储占用一起记录
+}
+
+// PhaseSpec 场景中的一个阶段，未设置的字段取配置文件 workload 中的值
+type PhaseSpec struct {
+	Op           string        `json:"op" mapstructure:"op"`                     // 操作类型: create、get、update、upsert、tx_rmw、delete、insert_batch、soak_create、soak_mixed
+	Name         string        `json:"name" mapstructure:"name"`                 // 结果中的阶段名称，为空时为 op；同一 op 出现多次时用于区分
+	Count        int           `json:"count" mapstructure:"count"`               // 操作次数，insert_batch 为总行数
+	Duration     time.Duration `json:"duration" mapstructure:"duration"`         // soak_* 的持续时间
+	Interval     time.Duration `json:"interval" mapstructure:"interval"`         // soak_* 的时间序列统计间隔
+	Concurrency  int           `json:"concurrency" mapstructure:"concurrency"`   // 并发 worker 数
+	Distribution string        `json:"distribution" mapstructure:"distribution"` // get、update 的主键访问分布，取值同 workload.distribution
+	BatchSize    int           `json:"batch_size" mapstructure:"batch_size"`     // insert_batch 每条 INSERT 的行数
+	Timeout      time.Duration `json:"timeout" mapstructure:"timeout"`           // 本阶段的超时，覆盖 workload.phase_timeout
+}
+
+// ScenarioOps 场景阶段支持的操作类型
+var ScenarioOps = []string{"create", "get", "update", "upsert", "tx_rmw", "delete", "insert_batch", "soak_create", "soak_mixed"}
+
+// createTablePattern 从建表语句中取出表名
+var createTablePattern = regexp.MustCompile("(?is)^\\s*CREATE\\s+TABLE\\s+(?:IF\\s+NOT\\s+EXISTS\\s+)?`?([A-Za-z0-9_$]+)`?")
+
+// DDLTable 返回 DDL 创建的表名，DDL 不是 CREATE TABLE 语句时返回空
+func (s *Scenario) DDLTable() string {
+	return ddlTable(s.DDL)
+}
+
+// DDLFor 返回数据库类型 dbType 上执行的建表语句
+func (s *Scenario) DDLFor(dbType string) string {
+	if dbType == DatabaseSQLite && s.DDLSQLite != "" {
+		return s.DDLSQLite
+	}
+	return s.DDL
+}
+
+// ddlTable 返回建表语句 ddl 创建的表名，不是 CREATE TABLE 语句时返回空
+func ddlTable(ddl string) string {
+	m := createTablePattern.FindStringSubmatch(stripSQLComments(ddl))
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// SoakOp 返回 soak_* 阶段的长时间运行负载（create 或 mixed），其他阶段返回空
+func (p *PhaseSpec) SoakOp() string {
+	op, ok := strings.CutPrefix(p.Op, "soak_")
+	if !ok {
+		return ""
+	}
+	return op
+}
+
+// Apply 返回以 w 为基础、叠加本阶段设置后的负载参数
+func (p *PhaseSpec) Apply(w WorkloadConfig) WorkloadConfig {
+	if p.Count > 0 {
+		if p.Op == "insert_batch" {
+			w.BatchTotalRows = p.Count
+		} else {
+			w.PhaseOps = p.Count
+		}
+	}
+	if p.Duration > 0 {
+		w.SoakDuration = p.Duration
+	}
+	if p.Interval > 0 {
+		w.SoakInterval = p.Interval
+	}
+	if p.Concurrency > 0 {
+		w.Concurrency = p.Concurrency
+	}
+	if p.Distribution != "" {
+		w.Distribution = p.Distribution
+	}
+	if p.BatchSize > 0 {
+		w.BatchSize = p.BatchSize
+	}
+	if p.Timeout > 0 {
+		w.PhaseTimeout = p.Timeout
+	}
+	return w
+}
+
+// Validate 检查场景中的所有字段，一次性返回全部问题，每行以字段路径开头
+func (s *Scenario) Validate() error {
+	var errs configErrors
+	if s.Name == "" {
+		errs.addf("name", "不能为空")
+	}
+	if s.DDL != "" {
+		ddlTable := s.DDLTable()
+		switch {
+		case ddlTable == "":
+			errs.addf("ddl", "应为一条 CREATE TABLE 语句")
+		case s.Table != "" && s.Table != ddlTable:
+			errs.addf("table", "与 ddl 创建的表 %q 不一致", ddlTable)
+		}
+	}
+	if s.DDLSQLite != "" {
+		switch sqliteTable := ddlTable(s.DDLSQLite); {
+		case s.DDL == "":
+			errs.addf("ddl_sqlite", "需要同时写出 ddl")
+		case sqliteTable == "":
+			errs.addf("ddl_sqlite", "应为一条 CREATE TABLE 语句")
+		case sqliteTable != s.DDLTable():
+			errs.addf("ddl_sqlite", "创建的表 %q 与 ddl 创建的表 %q 不一致", sqliteTable, s.DDLTable())
+		}
+	}
+	if s.Table != "" && !databaseNamePattern.MatchString(s.Table) {
+		errs.addf("table", "表名 %q 只能包含字母、数字、下划线与 $", s.Table)
+	}
+
+	if p := s.Preload; p != nil {
+		if p.Rows < 1 {
+			errs.addf("preload.rows", "至少为 1，当前为 %d", p.Rows)
+		}
+		errs.nonNegative("preload.batch_size", p.BatchSize)
+		errs.nonNegative("preload.concurrency", p.Concurrency)
+	}
+
+	if len(s.Phases) == 0 {
+		errs.addf("phases", "至少需要一个阶段")
+	}
+	for i := range s.Phases {
+		p := &s.Phases[i]
+		path := fmt.Sprintf("phases[%d]", i)
+		errs.oneOf(path+".op", p.Op, ScenarioOps...)
+		errs.nonNegative(path+".count", p.Count)
+		errs.nonNegativeDuration(path+".duration", p.Duration)
+		errs.nonNegativeDuration(path+".interval", p.Interval)
+		errs.nonNegative(path+".concurrency", p.Concurrency)
+		errs.nonNegative(path+".batch_size", p.BatchSize)
+		errs.nonNegativeDuration(path+".timeout", p.Timeout)
+		errs.oneOf(path+".distribution", p.Distribution, Distributions...)
+		if p.SoakOp() != "" {
+			if p.Count > 0 {
+				errs.addf(path+".count", "%s 按 duration 运行，不能设置 count", p.Op)
+			}
+		} else if p.Duration > 0 || p.Interval > 0 {
+			errs.addf(path+".duration", "只有 soak_create、soak_mixed 可以设置 duration 与 interval")
+		}
+		if p.Distribution != "" && p.Op != "get" && p.Op != "update" {
+			errs.addf(path+".distribution", "只有 get、update 可以设置主键访问分布")
+		}
+		if p.BatchSize > 0 && p.Op != "insert_batch" {
+			errs.addf(path+".batch_size", "只有 insert_batch 可以设置 batch_size")
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stripSQLComments 去掉 SQL 中以 -- 开头的注释行
+func stripSQLComments(sql string) string {
+	var lines []string
+	for _, line := range strings.Split(sql, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
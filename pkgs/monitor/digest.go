@@ -0,0 +1,45 @@
+package monitor
+
+import (
+	"log/slog"
+
+	"db_optimization_techs/pkgs/dals"
+	"db_optimization_techs/pkgs/models"
+
+	"gorm.io/gorm"
+)
+
+// defaultDigestLimit 每个阶段默认记录的语句摘要条数
+const defaultDigestLimit = 20
+
+// DigestObserver 在每个阶段开始前清空 performance_schema 语句摘要，结束后读取按总耗时排序的摘要写入阶段结果，
+// 用于区分服务端执行时间与客户端、GORM 的开销
+type DigestObserver struct {
+	db    *gorm.DB
+	limit int
+}
+
+// NewDigestObserver 创建 DigestObserver 实例，limit <= 0 时每个阶段记录 20 条
+func NewDigestObserver(db *gorm.DB, limit int) *DigestObserver {
+	if limit <= 0 {
+		limit = defaultDigestLimit
+	}
+	return &DigestObserver{db: db, limit: limit}
+}
+
+// PhaseStart 清空语句摘要
+func (o *DigestObserver) PhaseStart(phase string) {
+	if err := dals.ResetStatementDigests(o.db); err != nil {
+		slog.Warn("阶段开始前重置语句摘要失败", "phase", phase, "error", err)
+	}
+}
+
+// PhaseEnd 读取语句摘要写入 result.Digests
+func (o *DigestObserver) PhaseEnd(phase string, result *models.PhaseResult) {
+	digests, err := dals.StatementDigests(o.db, o.limit)
+	if err != nil {
+		slog.Warn("阶段结束后读取语句摘要失败", "phase", phase, "error", err)
+		return
+	}
+	result.Digests = digests
+}
@@ -0,0 +1,208 @@
+package monitor
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"db_optimization_techs/pkgs/models"
+)
+
+// defaultHostInterval 未配置时的主机资源采样间隔
+const defaultHostInterval = time.Second
+
+// hostSample 一次采样得到的各项累计计数
+type hostSample struct {
+	at      time.Time
+	cpu     cpuTimes
+	mem     memInfo
+	disk    diskCounters
+	process processStats
+}
+
+// HostObserver 在每个阶段运行期间按固定间隔读取 /proc 采样主机 CPU、内存、磁盘与压测进程自身的资源占用，
+// 阶段结束时把各指标的平均值与峰值写入阶段结果的 Host；数据库需与压测程序运行在同一台 Linux 主机上
+type HostObserver struct {
+	interval time.Duration
+	devices  []string
+
+	mu      sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+	rates   []hostRates
+	lastErr error
+}
+
+// hostRates 相邻两次采样之间计算出的速率与瞬时值
+type hostRates struct {
+	cpuPercent       float64
+	memUsedMB        float64
+	diskIOPS         float64
+	diskReadMBps     float64
+	diskWriteMBps    float64
+	diskAwaitMs      float64
+	clientCPUPercent float64
+	clientRSSMB      float64
+}
+
+// NewHostObserver 创建 HostObserver 实例
+// interval 为采样间隔（<= 0 时为 1 秒）；devices 为需要统计的块设备名（如 vda），为空时统计所有整盘设备
+func NewHostObserver(interval time.Duration, devices []string) *HostObserver {
+	if interval <= 0 {
+		interval = defaultHostInterval
+	}
+	return &HostObserver{interval: interval, devices: devices}
+}
+
+// PhaseStart 启动后台采样
+func (o *HostObserver) PhaseStart(phase string) {
+	o.mu.Lock()
+	o.stop = make(chan struct{})
+	o.done = make(chan struct{})
+	o.rates = nil
+	o.lastErr = nil
+	stop, done := o.stop, o.done
+	o.mu.Unlock()
+
+	go o.sampleLoop(stop, done)
+}
+
+// PhaseEnd 停止采样，汇总平均值与峰值写入 result.Host
+func (o *HostObserver) PhaseEnd(phase string, result *models.PhaseResult) {
+	o.mu.Lock()
+	stop, done := o.stop, o.done
+	o.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.lastErr != nil {
+		slog.Warn("采样主机资源失败", "phase", phase, "error", o.lastErr)
+	}
+	if len(o.rates) > 0 {
+		result.Host = summarizeHostRates(o.rates)
+	}
+	o.stop, o.done = nil, nil
+}
+
+// sampleLoop 每隔 interval 采样一次，直到 stop 关闭；停止时再补采一次，保证短阶段也至少有一个区间
+func (o *HostObserver) sampleLoop(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	prev, err := takeHostSample(o.devices)
+	if err != nil {
+		o.recordError(err)
+		return
+	}
+
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+	for {
+		stopped := false
+		select {
+		case <-ticker.C:
+		case <-stop:
+			stopped = true
+		}
+
+		cur, err := takeHostSample(o.devices)
+		if err != nil {
+			o.recordError(err)
+			return
+		}
+		if cur.at.Sub(prev.at) > 0 {
+			o.mu.Lock()
+			o.rates = append(o.rates, computeHostRates(prev, cur))
+			o.mu.Unlock()
+		}
+		prev = cur
+
+		if stopped {
+			return
+		}
+	}
+}
+
+// recordError 记录采样错误
+func (o *HostObserver) recordError(err error) {
+	o.mu.Lock()
+	o.lastErr = err
+	o.mu.Unlock()
+}
+
+// takeHostSample 读取一次 /proc 中的各项累计计数
+func takeHostSample(devices []string) (hostSample, error) {
+	sample := hostSample{at: time.Now()}
+	var err error
+	if sample.cpu, err = readCPUTimes(); err != nil {
+		return sample, err
+	}
+	if sample.mem, err = readMemInfo(); err != nil {
+		return sample, err
+	}
+	if sample.disk, err = readDiskCounters(devices); err != nil {
+		return sample, err
+	}
+	if sample.process, err = readProcessStats(); err != nil {
+		return sample, err
+	}
+	return sample, nil
+}
+
+// computeHostRates 根据相邻两次采样计算区间内的速率
+func computeHostRates(prev, cur hostSample) hostRates {
+	seconds := cur.at.Sub(prev.at).Seconds()
+	var rates hostRates
+
+	if total := cur.cpu.total - prev.cpu.total; total > 0 {
+		idle := cur.cpu.idle - prev.cpu.idle
+		rates.cpuPercent = float64(total-idle) / float64(total) * 100
+	}
+	rates.memUsedMB = float64(cur.mem.totalKB-cur.mem.availableKB) / 1024
+
+	ios := (cur.disk.reads - prev.disk.reads) + (cur.disk.writes - prev.disk.writes)
+	rates.diskIOPS = float64(ios) / seconds
+	rates.diskReadMBps = float64(cur.disk.sectorsRead-prev.disk.sectorsRead) * diskSectorBytes / 1024 / 1024 / seconds
+	rates.diskWriteMBps = float64(cur.disk.sectorsWrite-prev.disk.sectorsWrite) * diskSectorBytes / 1024 / 1024 / seconds
+	if ios > 0 {
+		waitMs := (cur.disk.readTimeMs - prev.disk.readTimeMs) + (cur.disk.writeTimeMs - prev.disk.writeTimeMs)
+		rates.diskAwaitMs = float64(waitMs) / float64(ios)
+	}
+
+	cpuSeconds := float64(cur.process.cpuJiffies-prev.process.cpuJiffies) / userHZ
+	rates.clientCPUPercent = cpuSeconds / seconds * 100
+	rates.clientRSSMB = float64(cur.process.rssKB) / 1024
+	return rates
+}
+
+// summarizeHostRates 计算各指标在阶段内的平均值与峰值
+func summarizeHostRates(rates []hostRates) *models.HostStats {
+	summarize := func(value func(hostRates) float64) models.MetricSummary {
+		var summary models.MetricSummary
+		for _, r := range rates {
+			v := value(r)
+			summary.Avg += v
+			if v > summary.Peak {
+				summary.Peak = v
+			}
+		}
+		summary.Avg /= float64(len(rates))
+		return summary
+	}
+
+	return &models.HostStats{
+		Samples:          len(rates),
+		CPUPercent:       summarize(func(r hostRates) float64 { return r.cpuPercent }),
+		MemUsedMB:        summarize(func(r hostRates) float64 { return r.memUsedMB }),
+		DiskIOPS:         summarize(func(r hostRates) float64 { return r.diskIOPS }),
+		DiskReadMBps:     summarize(func(r hostRates) float64 { return r.diskReadMBps }),
+		DiskWriteMBps:    summarize(func(r hostRates) float64 { return r.diskWriteMBps }),
+		DiskAwaitMs:      summarize(func(r hostRates) float64 { return r.diskAwaitMs }),
+		ClientCPUPercent: summarize(func(r hostRates) float64 { return r.clientCPUPercent }),
+		ClientRSSMB:      summarize(func(r hostRates) float64 { return r.clientRSSMB }),
+	}
+}
@@ -0,0 +1,129 @@
+package monitor
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"db_optimization_techs/pkgs/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsNamespace Prometheus 指标名前缀
+const metricsNamespace = "dbbench"
+
+// phaseMetrics 当前阶段对应标签的指标实例，避免每次操作都按标签查找
+type phaseMetrics struct {
+	ops      prometheus.Counter
+	errors   prometheus.Counter
+	latency  prometheus.Observer
+	inFlight prometheus.Gauge
+}
+
+// Metrics 压测运行期间的 Prometheus 指标：按阶段与主键策略统计的操作数、错误数、延迟直方图、
+// 进行中的操作数，以及 sql.DB 连接池状态；同时实现 PhaseObserver 与 OpObserver，注册到服务后自动更新
+type Metrics struct {
+	registry *prometheus.Registry
+	strategy string
+
+	ops      *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+	running  *prometheus.GaugeVec
+
+	current atomic.Pointer[phaseMetrics]
+}
+
+// NewMetrics 创建 Metrics 实例，strategy 为主键策略标签；sqlDB 不为 nil 时同时导出连接池状态
+func NewMetrics(strategy string, sqlDB *sql.DB, dbName string) *Metrics {
+	labels := []string{"phase", "strategy"}
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		strategy: strategy,
+		ops: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace, Name: "ops_total", Help: "已完成的操作数",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace, Name: "errors_total", Help: "失败的操作数",
+		}, labels),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace, Name: "op_latency_seconds", Help: "单次操作延迟",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 2, 16), // 0.5ms ~ 16s
+		}, labels),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace, Name: "in_flight_ops", Help: "正在执行的操作数（即忙碌的 worker 数）",
+		}, labels),
+		running: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace, Name: "phase_running", Help: "阶段是否正在运行（1 为运行中）",
+		}, labels),
+	}
+
+	m.registry.MustRegister(m.ops, m.errors, m.latency, m.inFlight, m.running)
+	m.registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	if sqlDB != nil {
+		m.registry.MustRegister(collectors.NewDBStatsCollector(sqlDB, dbName))
+	}
+	return m
+}
+
+// Handler 返回暴露指标的 HTTP Handler
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Serve 在 addr 上启动 HTTP 服务，在 /metrics 暴露指标；返回的 *http.Server 可用于关闭
+func (m *Metrics) Serve(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Warn("指标服务退出", "addr", addr, "error", err)
+		}
+	}()
+	return server
+}
+
+// PhaseStart 切换当前阶段的指标实例并标记阶段运行中
+func (m *Metrics) PhaseStart(phase string) {
+	m.current.Store(&phaseMetrics{
+		ops:      m.ops.WithLabelValues(phase, m.strategy),
+		errors:   m.errors.WithLabelValues(phase, m.strategy),
+		latency:  m.latency.WithLabelValues(phase, m.strategy),
+		inFlight: m.inFlight.WithLabelValues(phase, m.strategy),
+	})
+	m.running.WithLabelValues(phase, m.strategy).Set(1)
+}
+
+// PhaseEnd 标记阶段结束
+func (m *Metrics) PhaseEnd(phase string, result *models.PhaseResult) {
+	m.running.WithLabelValues(phase, m.strategy).Set(0)
+}
+
+// OpStart 增加进行中的操作数
+func (m *Metrics) OpStart(phase string) {
+	if current := m.current.Load(); current != nil {
+		current.inFlight.Inc()
+	}
+}
+
+// OpDone 记录一次操作的结果与延迟
+func (m *Metrics) OpDone(phase string, latency time.Duration, err error) {
+	current := m.current.Load()
+	if current == nil {
+		return
+	}
+	current.inFlight.Dec()
+	current.ops.Inc()
+	current.latency.Observe(latency.Seconds())
+	if err != nil {
+		current.errors.Inc()
+	}
+}
@@ -0,0 +1,211 @@
+package monitor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// procRoot procfs 挂载点
+const procRoot = "/proc"
+
+// sysBlockRoot sysfs 中块设备目录
+const sysBlockRoot = "/sys/block"
+
+// userHZ /proc 中 CPU 时间的计量单位（jiffies/秒），Linux 用户态接口固定为 100
+const userHZ = 100
+
+// diskSectorBytes /proc/diskstats 中扇区计数的固定单位
+const diskSectorBytes = 512
+
+// cpuTimes /proc/stat 中汇总 cpu 行的累计时间（jiffies）
+type cpuTimes struct {
+	total uint64 // 所有状态时间之和
+	idle  uint64 // idle + iowait
+}
+
+// diskCounters /proc/diskstats 中一组块设备的累计计数之和
+type diskCounters struct {
+	reads        uint64 // 完成的读请求数
+	writes       uint64 // 完成的写请求数
+	sectorsRead  uint64 // 读取的扇区数
+	sectorsWrite uint64 // 写入的扇区数
+	readTimeMs   uint64 // 读请求累计耗时（毫秒）
+	writeTimeMs  uint64 // 写请求累计耗时（毫秒）
+}
+
+// memInfo /proc/meminfo 中的内存信息（KB）
+type memInfo struct {
+	totalKB     uint64
+	availableKB uint64
+}
+
+// processStats 当前进程的 CPU 时间与常驻内存
+type processStats struct {
+	cpuJiffies uint64 // utime + stime
+	rssKB      uint64 // VmRSS
+}
+
+// readCPUTimes 读取 /proc/stat 第一行的汇总 CPU 时间
+func readCPUTimes() (cpuTimes, error) {
+	file, err := os.Open(filepath.Join(procRoot, "stat"))
+	if err != nil {
+		return cpuTimes{}, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+		// 只累加 user 到 steal 的前 8 列；之后的 guest、guest_nice 已计入 user、nice，重复累加会低估 CPU 使用率
+		columns := fields[1:]
+		if len(columns) > 8 {
+			columns = columns[:8]
+		}
+		var times cpuTimes
+		for i, field := range columns {
+			value, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return cpuTimes{}, fmt.Errorf("解析 /proc/stat 失败: %w", err)
+			}
+			times.total += value
+			// 第 4、5 列分别为 idle 与 iowait
+			if i == 3 || i == 4 {
+				times.idle += value
+			}
+		}
+		return times, nil
+	}
+	return cpuTimes{}, fmt.Errorf("/proc/stat 中没有 cpu 汇总行")
+}
+
+// readMemInfo 读取 /proc/meminfo 中的总内存与可用内存
+func readMemInfo() (memInfo, error) {
+	file, err := os.Open(filepath.Join(procRoot, "meminfo"))
+	if err != nil {
+		return memInfo{}, err
+	}
+	defer file.Close()
+
+	var info memInfo
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			info.totalKB = value
+		case "MemAvailable:":
+			info.availableKB = value
+		}
+	}
+	return info, scanner.Err()
+}
+
+// readDiskCounters 读取 /proc/diskstats 并累加 devices 中各设备的计数
+// devices 为空时累加所有整盘设备（/sys/block 下存在的设备，排除分区、loop、ram 设备与 dm、md 等建立在其他磁盘之上的设备）
+func readDiskCounters(devices []string) (diskCounters, error) {
+	file, err := os.Open(filepath.Join(procRoot, "diskstats"))
+	if err != nil {
+		return diskCounters{}, err
+	}
+	defer file.Close()
+
+	wanted := make(map[string]bool, len(devices))
+	for _, device := range devices {
+		wanted[device] = true
+	}
+
+	var counters diskCounters
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 14 {
+			continue
+		}
+		name := fields[2]
+		if len(wanted) > 0 {
+			if !wanted[name] {
+				continue
+			}
+		} else if !isWholeDisk(sysBlockRoot, name) {
+			continue
+		}
+
+		values := make([]uint64, 0, 11)
+		for _, field := range fields[3:14] {
+			value, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return diskCounters{}, fmt.Errorf("解析 /proc/diskstats 失败: %w", err)
+			}
+			values = append(values, value)
+		}
+		counters.reads += values[0]
+		counters.sectorsRead += values[2]
+		counters.readTimeMs += values[3]
+		counters.writes += values[4]
+		counters.sectorsWrite += values[6]
+		counters.writeTimeMs += values[7]
+	}
+	return counters, scanner.Err()
+}
+
+// isWholeDisk 判断 sysBlock 下的块设备是否为整盘设备（而非分区或虚拟设备）
+// slaves 目录非空的设备（LVM、dm-crypt、软 RAID 等）的 IO 已计入其下层磁盘，一并排除以免重复累加
+func isWholeDisk(sysBlock, name string) bool {
+	if strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(sysBlock, name)); err != nil {
+		return false
+	}
+	slaves, _ := os.ReadDir(filepath.Join(sysBlock, name, "slaves"))
+	return len(slaves) == 0
+}
+
+// readProcessStats 读取当前进程的 CPU 时间（/proc/self/stat）与常驻内存（/proc/self/status）
+func readProcessStats() (processStats, error) {
+	data, err := os.ReadFile(filepath.Join(procRoot, "self", "stat"))
+	if err != nil {
+		return processStats{}, err
+	}
+	// 进程名可能包含空格，从最后一个 ')' 之后开始解析；其后第 12、13 个字段为 utime、stime
+	content := string(data)
+	fields := strings.Fields(content[strings.LastIndexByte(content, ')')+1:])
+	if len(fields) < 13 {
+		return processStats{}, fmt.Errorf("解析 /proc/self/stat 失败: 字段不足")
+	}
+	var stats processStats
+	for _, field := range fields[11:13] {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return processStats{}, fmt.Errorf("解析 /proc/self/stat 失败: %w", err)
+		}
+		stats.cpuJiffies += value
+	}
+
+	status, err := os.ReadFile(filepath.Join(procRoot, "self", "status"))
+	if err != nil {
+		return processStats{}, err
+	}
+	for _, line := range strings.Split(string(status), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "VmRSS:" {
+			stats.rssKB, _ = strconv.ParseUint(fields[1], 10, 64)
+			break
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,36 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsWholeDisk(t *testing.T) {
+	root := t.TempDir()
+	mkdir := func(path ...string) {
+		if err := os.MkdirAll(filepath.Join(append([]string{root}, path...)...), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 与 /sys/block 相同的布局：分区不出现在顶层，dm、md 设备的 slaves 下是其下层设备
+	mkdir("sda", "slaves")
+	mkdir("nvme0n1")
+	mkdir("dm-0", "slaves", "sda2")
+	mkdir("md0", "slaves", "sdb")
+	mkdir("md0", "slaves", "sdc")
+	mkdir("loop0", "slaves")
+
+	for name, want := range map[string]bool{
+		"sda":     true,
+		"nvme0n1": true,
+		"sda1":    false,
+		"dm-0":    false,
+		"md0":     false,
+		"loop0":   false,
+	} {
+		if got := isWholeDisk(root, name); got != want {
+			t.Errorf("isWholeDisk(%s) = %v，预期 %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"db_optimization_techs/pkgs/models"
+	"db_optimization_techs/pkgs/stats"
+)
+
+const (
+	// ttyRefreshInterval 终端模式下的刷新间隔
+	ttyRefreshInterval = 500 * time.Millisecond
+	// defaultProgressLogInterval 非终端模式下未配置时输出进度日志的间隔
+	defaultProgressLogInterval = 10 * time.Second
+	// progressWindows 滚动吞吐与 p99 统计覆盖的刷新间隔个数
+	progressWindows = 10
+)
+
+// progressWindow 一个刷新间隔内完成的操作延迟
+type progressWindow struct {
+	start     time.Time
+	end       time.Time
+	latencies []time.Duration
+}
+
+// Progress 阶段运行期间的实时进度展示：当前阶段、已完成/计划操作数、滚动吞吐、滚动 p99、错误数与预计剩余时间
+// 标准输出为终端时原地刷新单行状态，否则按固定间隔输出普通日志行，适合重定向到文件的长时间运行
+type Progress struct {
+	out      io.Writer
+	tty      bool
+	interval time.Duration
+
+	done   atomic.Int64
+	errors atomic.Int64
+
+	mu       sync.Mutex
+	phase    string
+	total    int64
+	duration time.Duration
+	start    time.Time
+	current  []time.Duration
+	windows  []progressWindow
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// NewProgress 创建 Progress 实例，输出到标准输出
+// logInterval 为非终端模式下输出进度日志的间隔（<= 0 时为 10 秒），终端模式固定每 500ms 刷新
+func NewProgress(logInterval time.Duration) *Progress {
+	tty := isTerminal(os.Stdout)
+	interval := logInterval
+	if tty {
+		interval = ttyRefreshInterval
+	} else if interval <= 0 {
+		interval = defaultProgressLogInterval
+	}
+	return &Progress{out: os.Stdout, tty: tty, interval: interval}
+}
+
+// isTerminal 判断文件是否为字符设备（终端）
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// PhasePlan 记录阶段的计划操作数或计划时长，用于计算进度与剩余时间
+func (p *Progress) PhasePlan(phase string, total int64, duration time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total = total
+	p.duration = duration
+}
+
+// PhaseStart 重置计数并启动后台刷新
+func (p *Progress) PhaseStart(phase string) {
+	p.done.Store(0)
+	p.errors.Store(0)
+
+	p.mu.Lock()
+	p.phase = phase
+	p.start = time.Now()
+	p.current = nil
+	p.windows = nil
+	p.stop = make(chan struct{})
+	p.stopped = make(chan struct{})
+	stop, stopped := p.stop, p.stopped
+	p.mu.Unlock()
+
+	go p.refreshLoop(stop, stopped)
+}
+
+// PhaseEnd 停止刷新并输出阶段的最终进度，随后清空计划规模，避免影响下一个未声明规模的阶段
+func (p *Progress) PhaseEnd(phase string, result *models.PhaseResult) {
+	p.mu.Lock()
+	stop, stopped := p.stop, p.stopped
+	p.stop = nil
+	p.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-stopped
+
+	p.render(time.Now(), true)
+
+	p.mu.Lock()
+	p.total = 0
+	p.duration = 0
+	p.mu.Unlock()
+}
+
+// OpStart 无需处理
+func (p *Progress) OpStart(phase string) {}
+
+// OpDone 累加完成数与错误数，并记录延迟用于滚动 p99
+func (p *Progress) OpDone(phase string, latency time.Duration, err error) {
+	p.done.Add(1)
+	if err != nil {
+		p.errors.Add(1)
+	}
+	p.mu.Lock()
+	p.current = append(p.current, latency)
+	p.mu.Unlock()
+}
+
+// refreshLoop 按刷新间隔轮转统计窗口并输出进度，直到 stop 被关闭
+func (p *Progress) refreshLoop(stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			p.render(now, false)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// render 把当前间隔的延迟并入滚动窗口，计算滚动吞吐、p99 与剩余时间并输出一行进度
+// final 为 true 时输出阶段结束的最终状态
+func (p *Progress) render(now time.Time, final bool) {
+	p.mu.Lock()
+	windowStart := p.start
+	if n := len(p.windows); n > 0 {
+		windowStart = p.windows[n-1].end
+	}
+	p.windows = append(p.windows, progressWindow{start: windowStart, end: now, latencies: p.current})
+	p.current = nil
+	if len(p.windows) > progressWindows {
+		p.windows = p.windows[len(p.windows)-progressWindows:]
+	}
+
+	var samples []time.Duration
+	for _, w := range p.windows {
+		samples = append(samples, w.latencies...)
+	}
+	span := now.Sub(p.windows[0].start)
+	phase, total, duration, start := p.phase, p.total, p.duration, p.start
+	p.mu.Unlock()
+
+	done := p.done.Load()
+	errs := p.errors.Load()
+	elapsed := now.Sub(start)
+
+	var rate float64
+	if span > 0 {
+		rate = float64(len(samples)) / span.Seconds()
+	}
+	p99 := stats.Summarize(samples).P99Ms
+
+	progress := fmt.Sprintf("%d", done)
+	eta := "-"
+	switch {
+	case total > 0:
+		progress = fmt.Sprintf("%d/%d (%.1f%%)", done, total, float64(done)*100/float64(total))
+		if remaining := total - done; remaining > 0 && rate > 0 {
+			eta = formatETA(time.Duration(float64(remaining) / rate * float64(time.Second)))
+		}
+	case duration > 0:
+		if remaining := duration - elapsed; remaining > 0 {
+			eta = formatETA(remaining)
+		}
+	}
+
+	if !p.tty {
+		if final {
+			slog.Info("阶段进度", "phase", phase, "done", done, "total", total, "errors", errs,
+				"elapsed", formatETA(elapsed), "finished", true)
+			return
+		}
+		slog.Info("阶段进度", "phase", phase, "done", done, "total", total, "ops_per_sec", math.Round(rate),
+			"p99_ms", p99, "errors", errs, "elapsed", formatETA(elapsed), "eta", eta)
+		return
+	}
+
+	line := fmt.Sprintf("[%s] 进度 %s, 吞吐 %.0f ops/s, p99 %.2fms, 错误 %d, 已用 %s, 剩余 %s",
+		phase, progress, rate, p99, errs, formatETA(elapsed), eta)
+	if final {
+		line = fmt.Sprintf("[%s] 完成 %s, 错误 %d, 耗时 %s", phase, progress, errs, formatETA(elapsed))
+	}
+	// 终端模式：回到行首清除整行后原地重写，阶段结束时换行保留最终状态
+	fmt.Fprintf(p.out, "\r\033[K%s", line)
+	if final {
+		fmt.Fprintln(p.out)
+	}
+}
+
+// formatETA 把时长格式化为 1h02m03s 形式，精确到秒
+func formatETA(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := int(d / time.Hour)
+	m := int(d % time.Hour / time.Minute)
+	s := int(d % time.Minute / time.Second)
+	if h > 0 {
+		return fmt.Sprintf("%dh%02dm%02ds", h, m, s)
+	}
+	if m > 0 {
+		return fmt.Sprintf("%dm%02ds", m, s)
+	}
+	return fmt.Sprintf("%ds", s)
+}
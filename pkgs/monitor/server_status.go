@@ -0,0 +1,110 @@
+package monitor
+
+import (
+	"log/slog"
+	"sync"
+
+	"db_optimization_techs/pkgs/dals"
+	"db_optimization_techs/pkgs/models"
+
+	"gorm.io/gorm"
+)
+
+// trackedInnodbMetrics 需要记录的 INNODB_METRICS 计数器，其中部分默认未启用，创建观察者时会尝试启用
+var trackedInnodbMetrics = []string{
+	"index_page_splits",
+	"index_page_merge_successful",
+	"index_page_reorg_successful",
+	"lock_row_lock_waits",
+	"lock_deadlocks",
+	"lock_timeouts",
+	"buffer_pool_reads",
+	"buffer_pool_read_requests",
+	"log_write_requests",
+}
+
+// trackedGlobalStatus 需要记录的 SHOW GLOBAL STATUS 计数器
+var trackedGlobalStatus = []string{
+	"Innodb_rows_inserted",
+	"Innodb_rows_read",
+	"Innodb_rows_updated",
+	"Innodb_rows_deleted",
+	"Innodb_buffer_pool_reads",
+	"Innodb_buffer_pool_read_requests",
+	"Innodb_buffer_pool_write_requests",
+	"Innodb_buffer_pool_pages_flushed",
+	"Innodb_os_log_written",
+	"Innodb_os_log_fsyncs",
+	"Innodb_data_fsyncs",
+	"Innodb_data_reads",
+	"Innodb_data_writes",
+	"Innodb_data_read",
+	"Innodb_data_written",
+	"Innodb_row_lock_waits",
+	"Innodb_row_lock_time",
+	"Innodb_deadlocks",
+	"Com_select",
+	"Com_insert",
+	"Com_update",
+	"Com_delete",
+}
+
+// ServerStatusObserver 在每个阶段前后采集 MySQL 的 GLOBAL STATUS 与 INNODB_METRICS，
+// 并将关注的计数器差值写入阶段结果的 ServerStatus
+type ServerStatusObserver struct {
+	db     *gorm.DB
+	mu     sync.Mutex
+	before map[string]map[string]int64 // 阶段名 -> 开始时的快照
+}
+
+// NewServerStatusObserver 创建 ServerStatusObserver 实例，并尝试启用默认关闭的 INNODB_METRICS 计数器
+// 启用失败（如权限不足）时只打印警告，对应计数器不会出现在结果中
+func NewServerStatusObserver(db *gorm.DB) *ServerStatusObserver {
+	if err := dals.EnableInnodbMetrics(db, trackedInnodbMetrics); err != nil {
+		slog.Warn("启用 INNODB_METRICS 计数器失败", "error", err)
+	}
+	return &ServerStatusObserver{db: db, before: make(map[string]map[string]int64)}
+}
+
+// PhaseStart 记录阶段开始时的计数器快照
+func (o *ServerStatusObserver) PhaseStart(phase string) {
+	snapshot, err := dals.SnapshotServerStatus(o.db)
+	if err != nil {
+		slog.Warn("阶段开始前采集服务端状态失败", "phase", phase, "error", err)
+		return
+	}
+	o.mu.Lock()
+	o.before[phase] = snapshot
+	o.mu.Unlock()
+}
+
+// PhaseEnd 采集阶段结束时的快照，计算关注计数器的差值写入 result.ServerStatus
+func (o *ServerStatusObserver) PhaseEnd(phase string, result *models.PhaseResult) {
+	o.mu.Lock()
+	before, ok := o.before[phase]
+	delete(o.before, phase)
+	o.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	after, err := dals.SnapshotServerStatus(o.db)
+	if err != nil {
+		slog.Warn("阶段结束后采集服务端状态失败", "phase", phase, "error", err)
+		return
+	}
+
+	deltas := make(map[string]int64)
+	keys := append([]string(nil), trackedGlobalStatus...)
+	for _, name := range trackedInnodbMetrics {
+		keys = append(keys, dals.InnodbMetricKey(name))
+	}
+	for _, key := range keys {
+		start, ok1 := before[key]
+		end, ok2 := after[key]
+		if ok1 && ok2 {
+			deltas[key] = end - start
+		}
+	}
+	result.ServerStatus = deltas
+}
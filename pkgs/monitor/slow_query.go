@@ -0,0 +1,37 @@
+package monitor
+
+import (
+	"sync/atomic"
+
+	"db_optimization_techs/pkgs/models"
+)
+
+// slowQueryCounter 慢查询计数来源，由 dals.GormLogger 实现
+type slowQueryCounter interface {
+	SlowQueries() int64
+}
+
+// SlowQueryObserver 统计每个阶段内的慢查询次数，写入阶段结果的 Counters["slow_queries"]
+// 与 count 模式的 GORM 日志配合使用，可以在不逐条打印的情况下了解慢查询的数量
+type SlowQueryObserver struct {
+	counter slowQueryCounter
+	before  atomic.Int64
+}
+
+// NewSlowQueryObserver 创建 SlowQueryObserver 实例
+func NewSlowQueryObserver(counter slowQueryCounter) *SlowQueryObserver {
+	return &SlowQueryObserver{counter: counter}
+}
+
+// PhaseStart 记录阶段开始前的累计次数
+func (o *SlowQueryObserver) PhaseStart(phase string) {
+	o.before.Store(o.counter.SlowQueries())
+}
+
+// PhaseEnd 写入阶段内的慢查询次数
+func (o *SlowQueryObserver) PhaseEnd(phase string, result *models.PhaseResult) {
+	if result.Counters == nil {
+		result.Counters = make(map[string]int64)
+	}
+	result.Counters["slow_queries"] = o.counter.SlowQueries() - o.before.Load()
+}
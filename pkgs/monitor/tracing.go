@@ -0,0 +1,93 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// tracingServiceName 上报链路时使用的服务名
+const tracingServiceName = "dbbench"
+
+// Tracing 链路追踪的导出配置，由 NewTracerProvider 使用
+type Tracing struct {
+	Exporter    string  // "otlp" 导出到 OTLP/HTTP 收集器，"file" 以 JSON Lines 写入文件
+	Endpoint    string  // OTLP/HTTP 收集器地址，如 "localhost:4318"
+	File        string  // file 导出器的输出文件路径
+	SampleRatio float64 // 采样比例，取值 (0, 1]，为 0 时全部采样
+}
+
+// NewTracerProvider 按配置创建 TracerProvider，strategy 作为资源属性 dbbench.strategy 随每个 span 上报，
+// 每次运行以随机 UUID 作为 service.instance.id 区分
+// 返回的 shutdown 会刷新尚未导出的 span 并关闭输出文件，运行结束前必须调用
+func NewTracerProvider(cfg Tracing, strategy string) (*sdktrace.TracerProvider, func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var file *os.File
+	switch cfg.Exporter {
+	case "otlp":
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint), otlptracehttp.WithInsecure())
+		}
+		otlp, err := otlptracehttp.New(context.Background(), opts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("创建 OTLP 导出器失败: %w", err)
+		}
+		exporter = otlp
+	case "file":
+		if cfg.File == "" {
+			return nil, nil, fmt.Errorf("file 导出器需要指定输出文件")
+		}
+		if dir := filepath.Dir(cfg.File); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, nil, fmt.Errorf("创建目录 %s 失败: %w", dir, err)
+			}
+		}
+		f, err := os.Create(cfg.File)
+		if err != nil {
+			return nil, nil, fmt.Errorf("创建链路文件 %s 失败: %w", cfg.File, err)
+		}
+		stdout, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("创建文件导出器失败: %w", err)
+		}
+		exporter, file = stdout, f
+	default:
+		return nil, nil, fmt.Errorf("不支持的链路导出器: %q（可选 otlp、file）", cfg.Exporter)
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(tracingServiceName),
+			semconv.ServiceInstanceID(uuid.NewString()),
+			attribute.String("dbbench.strategy", strategy),
+		)),
+	)
+
+	shutdown := func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if file != nil {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}
+	return provider, shutdown, nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"db_optimization_techs/pkgs/models"
+)
+
+// Benchmark 各主键策略的压测服务共同实现的接口，命令行据此按策略名选择服务而无需区分具体类型
+// 各阶段在 ctx 结束后停止发起新的操作，等待进行中的操作完成后返回标记为 Incomplete 的部分结果
+type Benchmark interface {
+	// AddObserver 注册阶段观察者
+	AddObserver(observer PhaseObserver)
+	// SetSlowestOps 设置每个阶段保留的最慢操作数
+	SetSlowestOps(n int)
+	// SetSeed 设置生成主键与随机选择的种子
+	SetSeed(seed uint64)
+	// SetOpTimeout 设置单次操作的超时
+	SetOpTimeout(d time.Duration)
+	// Phase 按名称返回压测阶段
+	Phase(name string, w *models.WorkloadConfig) (PhaseFunc, error)
+	// InsertBatch 以 batchSize 行一条 INSERT 的方式共插入 totalRows 行
+	InsertBatch(ctx context.Context, totalRows, batchSize, concurrency int) (*models.PhaseResult, error)
+	// Preload 与 InsertBatch 相同，但使用独立于 insert_batch 阶段的数据流
+	Preload(ctx context.Context, totalRows, batchSize, concurrency int) (*models.PhaseResult, error)
+	// EstimateRows 返回压测表行数的估算值
+	EstimateRows(ctx context.Context) (int64, error)
+	// Verify 校验最近一次执行的阶段写入的数据，阶段没有可校验的写入时返回 nil；须在阶段返回后、执行下一个阶段前调用
+	Verify(ctx context.Context, sample int) (*models.Verification, error)
+	// Soak 长时间运行模式
+	Soak(ctx context.Context, op string, duration, interval time.Duration, concurrency int, onInterval func(*models.SoakPoint)) (*models.PhaseResult, error)
+}
+
+var (
+	_ Benchmark = (*Test100mService)(nil)
+	_ Benchmark = (*Test100mCrc32Service)(nil)
+)
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// keyGen 按种子确定性地生成主键与随机选择
+// 每个阶段从 stream 取得独立的数据流，第 index 次操作的主键与随机数只由（种子、阶段名、该阶段第几次执行、index）决定，
+// 与 worker 调度顺序无关；因此同一种子对多个目标依次执行相同场景时，各目标收到完全相同的主键序列
+type keyGen struct {
+	seed uint64
+
+	mu     sync.Mutex
+	counts map[string]uint64 // 各阶段名已取得的数据流个数，并发度扫描等重复执行同一阶段时生成不同的主键
+}
+
+// newKeyGen 创建种子为 seed 的 keyGen
+func newKeyGen(seed uint64) *keyGen {
+	return &keyGen{seed: seed, counts: make(map[string]uint64)}
+}
+
+// stream 返回名为 name 的阶段下一次执行使用的数据流
+func (g *keyGen) stream(name string) *keyStream {
+	g.mu.Lock()
+	n := g.counts[name]
+	g.counts[name]++
+	g.mu.Unlock()
+
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return &keyStream{seed: g.seed, id: h.Sum64() + n}
+}
+
+// keyStream 一个阶段的确定性数据流，可并发使用
+type keyStream struct {
+	seed uint64
+	id   uint64
+}
+
+// rand 返回第 index 次操作专用的随机数生成器
+func (s *keyStream) rand(index int) *rand.Rand {
+	return rand.New(rand.NewPCG(s.seed^s.id, uint64(index)))
+}
+
+// uuid 返回第 index 次操作的主键，格式与 UUID v4 相同
+func (s *keyStream) uuid(index int) string {
+	return s.uuidFrom(s.rand(index))
+}
+
+// uuidFrom 用 r 生成一个 UUID v4 格式的主键，用于一次操作需要多个主键的场景
+func (s *keyStream) uuidFrom(r *rand.Rand) string {
+	var id uuid.UUID
+	for i := 0; i < len(id); i += 8 {
+		v := r.Uint64()
+		for j := 0; j < 8; j++ {
+			id[i+j] = byte(v >> (8 * j))
+		}
+	}
+	id[6] = (id[6] & 0x0f) | 0x40 // version 4
+	id[8] = (id[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return id.String()
+}
+
+// shuffle 以数据流确定的顺序打乱 keys
+func (s *keyStream) shuffle(keys []string) {
+	s.rand(-1).Shuffle(len(keys), func(i, j int) {
+		keys[i], keys[j] = keys[j], keys[i]
+	})
+}
+
+// zipfExponent Zipf 分布的指数，越大热点越集中
+const zipfExponent = 1.1
+
+// picker 返回按 distribution 从 keys 中选取第 index 次操作主键的函数，distribution 为空时使用 fallback
+// 可选值见 models.Distributions；shuffled 与 sequential 每个主键恰好访问一次，要求操作次数不超过 len(keys)
+func (s *keyStream) picker(distribution, fallback string, keys []string) (func(index int) string, error) {
+	if distribution == "" {
+		distribution = fallback
+	}
+	switch distribution {
+	case "sequential":
+		return func(index int) string { return keys[index] }, nil
+	case "shuffled":
+		shuffled := append([]string(nil), keys...)
+		s.shuffle(shuffled)
+		return func(index int) string { return shuffled[index] }, nil
+	case "uniform":
+		return func(index int) string { return keys[s.rand(index).IntN(len(keys))] }, nil
+	case "zipf":
+		imax := uint64(len(keys) - 1)
+		return func(index int) string {
+			return keys[rand.NewZipf(s.rand(index), zipfExponent, 1, imax).Uint64()]
+		}, nil
+	default:
+		return nil, fmt.Errorf("未知的主键访问分布: %s", distribution)
+	}
+}
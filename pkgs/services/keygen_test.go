@@ -0,0 +1,72 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestKeyStreamDeterministic(t *testing.T) {
+	a, b := newKeyGen(42).stream("create"), newKeyGen(42).stream("create")
+	for i := 0; i < 100; i++ {
+		if a.uuid(i) != b.uuid(i) {
+			t.Fatalf("相同种子第 %d 个主键不同", i)
+		}
+		id, err := uuid.Parse(a.uuid(i))
+		if err != nil || id.Version() != 4 || id.Variant() != uuid.RFC4122 {
+			t.Fatalf("主键 %s 不是 UUID v4 格式: %v", a.uuid(i), err)
+		}
+	}
+
+	g := newKeyGen(42)
+	first, second := g.stream("create"), g.stream("create")
+	if first.uuid(0) != a.uuid(0) || second.uuid(0) == first.uuid(0) {
+		t.Fatal("同一阶段重复执行时应生成不同的主键")
+	}
+	if newKeyGen(43).stream("create").uuid(0) == a.uuid(0) || g.stream("get").uuid(0) == a.uuid(0) {
+		t.Fatal("不同种子或不同阶段应生成不同的主键")
+	}
+}
+
+func TestPicker(t *testing.T) {
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = fmt.Sprint(i)
+	}
+	stream := newKeyGen(1).stream("get")
+
+	for _, distribution := range []string{"sequential", "shuffled"} {
+		pick, err := stream.picker(distribution, "", keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen := make(map[string]bool)
+		for i := range keys {
+			seen[pick(i)] = true
+		}
+		if len(seen) != len(keys) {
+			t.Fatalf("%s 应每个主键恰好访问一次，实际访问了 %d 个", distribution, len(seen))
+		}
+	}
+
+	// zipf 下越早插入的主键越热
+	pick, err := stream.picker("", "zipf", keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		counts[pick(i)]++
+	}
+	if counts["0"] <= counts["50"] || counts["0"] < 1000 {
+		t.Fatalf("zipf 分布不够集中: 第 0 个主键 %d 次，第 50 个 %d 次", counts["0"], counts["50"])
+	}
+	if again, _ := stream.picker("zipf", "", keys); again(7) != pick(7) {
+		t.Fatal("同一数据流第 index 次选取的主键应确定")
+	}
+
+	if _, err := stream.picker("unknown", "", keys); err == nil {
+		t.Fatal("未知的分布应返回错误")
+	}
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"math/rand/v2"
+	"sync"
+)
+
+// soakKeyPoolSize 长时间运行模式下保留的最近插入主键数，读写操作从中随机选取
+const soakKeyPoolSize = 100000
+
+// keyPool 固定容量的环形主键池，保存最近插入的主键，可并发访问
+type keyPool struct {
+	mu   sync.Mutex
+	keys []string
+	next int
+}
+
+// newKeyPool 创建容量为 size 的 keyPool
+func newKeyPool(size int) *keyPool {
+	return &keyPool{keys: make([]string, 0, size)}
+}
+
+// add 加入一个主键，池满时覆盖最早加入的主键
+func (p *keyPool) add(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.keys) < cap(p.keys) {
+		p.keys = append(p.keys, key)
+		return
+	}
+	p.keys[p.next] = key
+	p.next = (p.next + 1) % len(p.keys)
+}
+
+// random 用 r 随机返回池中的一个主键，池为空时 ok 为 false
+func (p *keyPool) random(r *rand.Rand) (key string, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.keys) == 0 {
+		return "", false
+	}
+	return p.keys[r.IntN(len(p.keys))], true
+}
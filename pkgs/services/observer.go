@@ -0,0 +1,32 @@
+package services
+
+import (
+	"time"
+
+	"db_optimization_techs/pkgs/models"
+)
+
+// PhaseObserver 阶段观察者，在每个阶段计时部分开始前与结束后被调用，
+// 用于采集服务端状态、主机资源等附加信息并写入阶段结果；准备数据的部分不在观察范围内
+type PhaseObserver interface {
+	// PhaseStart 阶段计时开始前调用
+	PhaseStart(phase string)
+	// PhaseEnd 阶段计时结束后调用，可将采集结果写入 result
+	PhaseEnd(phase string, result *models.PhaseResult)
+}
+
+// OpObserver 可选接口，实现了该接口的阶段观察者还会在每次操作开始与结束时被调用，
+// 用于实时指标、进度展示等；回调在 worker 协程中并发执行，实现需保证线程安全且足够轻量
+type OpObserver interface {
+	// OpStart 一次操作开始前调用
+	OpStart(phase string)
+	// OpDone 一次操作结束后调用
+	OpDone(phase string, latency time.Duration, err error)
+}
+
+// PlanObserver 可选接口，实现了该接口的阶段观察者会在 PhaseStart 之前收到阶段的计划规模，
+// 用于进度展示与剩余时间估算；total 为计划操作数，按时长运行的阶段 total 为 0、duration 为计划时长
+type PlanObserver interface {
+	// PhasePlan 阶段开始前调用
+	PhasePlan(phase string, total int64, duration time.Duration)
+}
@@ -0,0 +1,413 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"db_optimization_techs/pkgs/models"
+	"db_optimization_techs/pkgs/stats"
+)
+
+// defaultConcurrency 未指定并发度时使用的默认 worker 数
+const defaultConcurrency = 80
+
+// kneeGainThreshold 并发度翻倍后吞吐增幅低于该比例即视为达到拐点
+const kneeGainThreshold = 0.1
+
+// PhaseFunc 以指定并发度执行一次压测阶段，concurrency <= 0 时使用阶段默认值
+// ctx 结束后不再发起新的操作，等待进行中的操作完成后返回标记为 Incomplete 的部分结果
+type PhaseFunc func(ctx context.Context, concurrency int) (*models.PhaseResult, error)
+
+// BatchFunc 以指定总行数、批大小与并发度执行一次批量插入阶段
+type BatchFunc func(ctx context.Context, totalRows, batchSize, concurrency int) (*models.PhaseResult, error)
+
+// opFunc 阶段中的一次操作，index 为操作在阶段内的序号，返回操作访问的主键与错误
+// ctx 为单次操作的上下文，受单次操作超时限制
+type opFunc func(ctx context.Context, index int) (keys []string, err error)
+
+// runner 各服务共用的阶段执行器，负责并发执行、延迟统计并通知阶段观察者
+type runner struct {
+	observers     []PhaseObserver
+	opObservers   []OpObserver
+	planObservers []PlanObserver
+	slowestOps    int
+	opTimeout     time.Duration
+
+	keys *keyGen
+
+	// last 最近一次执行的阶段的结果，check 为该阶段的数据校验方式，供 verify 使用
+	last  *phaseOutcome
+	check *phaseCheck
+}
+
+// SetSeed 设置生成主键与随机选择的种子，须在执行阶段之前调用；
+// 相同种子下相同的阶段序列生成完全相同的主键，用于多个目标之间的对比；未设置时使用随机种子
+func (r *runner) SetSeed(seed uint64) {
+	r.keys = newKeyGen(seed)
+}
+
+// stream 返回名为 name 的阶段下一次执行使用的确定性数据流，在阶段开始前调用
+func (r *runner) stream(name string) *keyStream {
+	if r.keys == nil {
+		r.keys = newKeyGen(rand.Uint64())
+	}
+	return r.keys.stream(name)
+}
+
+// SetSlowestOps 设置每个阶段在结果中保留的最慢操作数，n <= 0 时不记录
+func (r *runner) SetSlowestOps(n int) {
+	r.slowestOps = n
+}
+
+// SetOpTimeout 设置单次操作（含准备数据时的每次写入）的超时，d <= 0 时不限制
+// 超时的操作计为失败，避免一条挂起的查询使整个阶段停滞
+func (r *runner) SetOpTimeout(d time.Duration) {
+	r.opTimeout = d
+}
+
+// opContext 返回单次操作使用的上下文：不随 ctx 取消，使中断时进行中的操作可以执行完毕，只受单次操作超时限制
+func (r *runner) opContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = context.WithoutCancel(ctx)
+	if r.opTimeout > 0 {
+		return context.WithTimeout(ctx, r.opTimeout)
+	}
+	return ctx, func() {}
+}
+
+// AddObserver 注册阶段观察者，按注册顺序在每个阶段计时部分的前后被调用；
+// 同时实现了 OpObserver 的观察者还会收到每次操作的回调，实现了 PlanObserver 的观察者还会收到阶段计划规模
+func (r *runner) AddObserver(observer PhaseObserver) {
+	r.observers = append(r.observers, observer)
+	if opObserver, ok := observer.(OpObserver); ok {
+		r.opObservers = append(r.opObservers, opObserver)
+	}
+	if planObserver, ok := observer.(PlanObserver); ok {
+		r.planObservers = append(r.planObservers, planObserver)
+	}
+}
+
+// phasePlan 通知计划观察者阶段的计划规模
+func (r *runner) phasePlan(phase string, total int64, duration time.Duration) {
+	for _, observer := range r.planObservers {
+		observer.PhasePlan(phase, total, duration)
+	}
+}
+
+// phaseStart 通知所有观察者阶段即将开始
+func (r *runner) phaseStart(phase string) {
+	for _, observer := range r.observers {
+		observer.PhaseStart(phase)
+	}
+}
+
+// phaseEnd 按注册的逆序通知观察者阶段已结束，使先注册的观察者最后采集
+func (r *runner) phaseEnd(phase string, result *models.PhaseResult) {
+	for i := len(r.observers) - 1; i >= 0; i-- {
+		r.observers[i].PhaseEnd(phase, result)
+	}
+}
+
+// runOp 由第 worker 个 worker 执行一次操作并通知操作观察者，耗时足够长时记入 slowest，返回操作耗时与错误
+func (r *runner) runOp(ctx context.Context, phase string, worker, index int, op opFunc, slowest *stats.Slowest) (time.Duration, error) {
+	opCtx, cancel := r.opContext(ctx)
+	defer cancel()
+	for _, observer := range r.opObservers {
+		observer.OpStart(phase)
+	}
+	start := time.Now()
+	keys, err := op(opCtx, index)
+	latency := time.Since(start)
+	for _, observer := range r.opObservers {
+		observer.OpDone(phase, latency, err)
+	}
+
+	if slowest.Admits(latency) {
+		slowOp := &models.SlowOp{Keys: keys, StartedAt: start, Worker: worker, Index: index}
+		if err != nil {
+			slowOp.Error = err.Error()
+		}
+		slowest.Add(latency, slowOp)
+	}
+	return latency, err
+}
+
+// runPhase 启动 concurrency 个 worker 共同执行 total 次 op，记录每次操作耗时并汇总为阶段结果
+// 失败的操作计入 Errors，返回的 error 为首个失败操作的错误；
+// ctx 结束后 worker 不再领取新的操作，进行中的操作完成后返回 Incomplete 的部分结果与中断原因
+func (r *runner) runPhase(ctx context.Context, phase string, total, concurrency int, op opFunc) (*models.PhaseResult, error) {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	recorder := stats.NewRecorder(total)
+	var next int64 = -1
+	var completed, errCount, mismatchCount int64
+	var firstErr error
+	var errOnce sync.Once
+	outcome := &phaseOutcome{phase: phase, failed: make(map[int]bool)}
+	var failedMu sync.Mutex
+	var wg sync.WaitGroup
+	slowest := stats.NewSlowest(r.slowestOps)
+	var slowestMu sync.Mutex
+
+	r.phasePlan(phase, int64(total), 0)
+	r.phaseStart(phase)
+	start := time.Now()
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 每个 worker 独立记录最慢操作，结束时合并，避免争用
+			workerSlowest := stats.NewSlowest(r.slowestOps)
+			defer func() {
+				slowestMu.Lock()
+				slowest.Merge(workerSlowest)
+				slowestMu.Unlock()
+			}()
+			for ctx.Err() == nil {
+				index := int(atomic.AddInt64(&next, 1))
+				if index >= total {
+					return
+				}
+
+				latency, err := r.runOp(ctx, phase, w, index, op, workerSlowest)
+				atomic.AddInt64(&completed, 1)
+				recorder.Record(latency)
+				if err != nil {
+					atomic.AddInt64(&errCount, 1)
+					if errors.Is(err, errRowsMismatch) {
+						atomic.AddInt64(&mismatchCount, 1)
+					}
+					errOnce.Do(func() { firstErr = err })
+					failedMu.Lock()
+					outcome.failed[index] = true
+					failedMu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	result := &models.PhaseResult{
+		Phase:        phase,
+		Concurrency:  concurrency,
+		Ops:          completed,
+		Errors:       errCount,
+		ElapsedMs:    elapsed.Milliseconds(),
+		Latency:      recorder.Summary(),
+		SlowestOps:   slowest.Sorted(),
+		Incomplete:   completed < int64(total),
+		RowsMismatch: mismatchCount,
+	}
+	if elapsed > 0 {
+		result.OpsPerSec = float64(completed) / elapsed.Seconds()
+	}
+	// worker 按序号领取操作且领取后必定执行，因此执行过的操作恰为 [0, completed)
+	outcome.completed = int(completed)
+	r.last, r.check = outcome, nil
+	r.phaseEnd(phase, result)
+	if result.Incomplete {
+		return result, fmt.Errorf("阶段 %s 完成 %d/%d 次操作后中断: %w", phase, completed, total, ctx.Err())
+	}
+	return result, firstErr
+}
+
+// Sweep 依次以 levels 中的并发度重复执行同一阶段，收集各并发度下的吞吐与 p99 延迟，并识别拐点
+// 任一并发度执行失败或 ctx 结束时返回已完成的部分结果和错误；单个并发度未完成（如阶段超时）时保留其部分结果并继续
+func Sweep(ctx context.Context, phase string, levels []int, run PhaseFunc) (*models.SweepResult, error) {
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("并发度扫描列表不能为空")
+	}
+	sorted := append([]int(nil), levels...)
+	sort.Ints(sorted)
+
+	sweep := &models.SweepResult{Phase: phase}
+	for _, concurrency := range sorted {
+		if concurrency <= 0 {
+			return sweep, fmt.Errorf("并发度必须大于 0: %d", concurrency)
+		}
+		result, err := run(ctx, concurrency)
+		if result != nil && result.Incomplete {
+			sweep.Points = append(sweep.Points, result)
+			if ctx.Err() != nil {
+				sweep.KneeConcurrency = findKnee(sweep.Points)
+				return sweep, fmt.Errorf("并发度 %d 执行中断: %w", concurrency, ctx.Err())
+			}
+			continue
+		}
+		if err != nil {
+			return sweep, fmt.Errorf("并发度 %d 执行失败: %w", concurrency, err)
+		}
+		sweep.Points = append(sweep.Points, result)
+	}
+	sweep.KneeConcurrency = findKnee(sweep.Points)
+	return sweep, nil
+}
+
+// findKnee 返回吞吐增长开始饱和的并发度：
+// 即第一个满足“下一档并发度的吞吐增幅不足 kneeGainThreshold”的并发度，若始终未饱和则返回最大并发度
+func findKnee(points []*models.PhaseResult) int {
+	if len(points) == 0 {
+		return 0
+	}
+	for i := 0; i+1 < len(points); i++ {
+		if points[i+1].OpsPerSec < points[i].OpsPerSec*(1+kneeGainThreshold) {
+			return points[i].Concurrency
+		}
+	}
+	return points[len(points)-1].Concurrency
+}
+
+// SweepBatchSizes 保持总行数不变，依次以 sizes 中的批大小执行批量插入，收集各批大小下的行吞吐与单条语句延迟
+// 任一批大小执行失败或 ctx 结束时返回已完成的部分结果和错误；单个批大小未完成（如阶段超时）时保留其部分结果并继续
+func SweepBatchSizes(ctx context.Context, totalRows int, sizes []int, concurrency int, run BatchFunc) (*models.BatchSweepResult, error) {
+	if len(sizes) == 0 {
+		return nil, fmt.Errorf("批大小扫描列表不能为空")
+	}
+	sorted := append([]int(nil), sizes...)
+	sort.Ints(sorted)
+
+	sweep := &models.BatchSweepResult{TotalRows: totalRows}
+	for _, batchSize := range sorted {
+		result, err := run(ctx, totalRows, batchSize, concurrency)
+		if result != nil && result.Incomplete {
+			sweep.Points = append(sweep.Points, result)
+			if ctx.Err() != nil {
+				return sweep, fmt.Errorf("批大小 %d 执行中断: %w", batchSize, ctx.Err())
+			}
+			continue
+		}
+		if err != nil {
+			return sweep, fmt.Errorf("批大小 %d 执行失败: %w", batchSize, err)
+		}
+		sweep.Points = append(sweep.Points, result)
+	}
+	return sweep, nil
+}
+
+// runSoak 启动 concurrency 个 worker 在 duration 内持续执行 op，每隔 interval 汇总一次吞吐与延迟并回调 onInterval
+// rowCount 用于在每个数据点中记录当前表行数；返回整个运行期间的阶段结果，延迟分布由直方图估算；
+// ctx 在 duration 之前结束时停止发起新的操作，返回 Incomplete 的部分结果与中断原因
+func (r *runner) runSoak(ctx context.Context, phase string, duration, interval time.Duration, concurrency int, op opFunc,
+	rowCount func() int64, onInterval func(*models.SoakPoint)) (*models.PhaseResult, error) {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	if duration <= 0 || interval <= 0 {
+		return nil, fmt.Errorf("运行时长与统计间隔必须大于 0: duration=%s, interval=%s", duration, interval)
+	}
+
+	overall := stats.NewHistogram()
+	window := stats.NewRecorder(0)
+	var next int64 = -1
+	var errCount, windowErrs, mismatchCount int64
+	var firstErr error
+	var errOnce sync.Once
+	var wg sync.WaitGroup
+	slowest := stats.NewSlowest(r.slowestOps)
+	var slowestMu sync.Mutex
+
+	r.phasePlan(phase, 0, duration)
+	r.phaseStart(phase)
+	start := time.Now()
+	deadline := start.Add(duration)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 每个 worker 独立记录最慢操作，结束时合并，避免争用
+			workerSlowest := stats.NewSlowest(r.slowestOps)
+			defer func() {
+				slowestMu.Lock()
+				slowest.Merge(workerSlowest)
+				slowestMu.Unlock()
+			}()
+			for ctx.Err() == nil && time.Now().Before(deadline) {
+				index := int(atomic.AddInt64(&next, 1))
+
+				latency, err := r.runOp(ctx, phase, w, index, op, workerSlowest)
+				overall.Record(latency)
+				window.Record(latency)
+				if err != nil {
+					atomic.AddInt64(&errCount, 1)
+					atomic.AddInt64(&windowErrs, 1)
+					if errors.Is(err, errRowsMismatch) {
+						atomic.AddInt64(&mismatchCount, 1)
+					}
+					errOnce.Do(func() { firstErr = err })
+				}
+			}
+		}()
+	}
+
+	// 每个间隔结束时输出一个数据点，最后一个间隔可能不足 interval
+	emit := func(now, windowStart time.Time) {
+		ops, latency := window.Drain()
+		point := &models.SoakPoint{
+			Time:       now,
+			ElapsedSec: now.Sub(start).Seconds(),
+			Ops:        int64(ops),
+			Errors:     atomic.SwapInt64(&windowErrs, 0),
+			Latency:    latency,
+			RowCount:   rowCount(),
+		}
+		if span := now.Sub(windowStart); span > 0 {
+			point.OpsPerSec = float64(ops) / span.Seconds()
+		}
+		if onInterval != nil {
+			onInterval(point)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	windowStart := start
+	for running := true; running; {
+		select {
+		case now := <-ticker.C:
+			emit(now, windowStart)
+			windowStart = now
+		case <-done:
+			running = false
+		}
+	}
+	end := time.Now()
+	emit(end, windowStart)
+
+	elapsed := end.Sub(start)
+	total := atomic.LoadInt64(&next) + 1
+	result := &models.PhaseResult{
+		Phase:        phase,
+		Concurrency:  concurrency,
+		Ops:          total,
+		Errors:       errCount,
+		ElapsedMs:    elapsed.Milliseconds(),
+		Latency:      overall.Summary(),
+		SlowestOps:   slowest.Sorted(),
+		Incomplete:   ctx.Err() != nil && end.Before(deadline),
+		RowsMismatch: mismatchCount,
+	}
+	if elapsed > 0 {
+		result.OpsPerSec = float64(total) / elapsed.Seconds()
+	}
+	// 长时间运行读写的主键随时间变化，不做阶段后校验
+	r.last, r.check = nil, nil
+	r.phaseEnd(phase, result)
+	if result.Incomplete {
+		return result, fmt.Errorf("阶段 %s 运行 %s 后中断: %w", phase, elapsed.Round(time.Millisecond), ctx.Err())
+	}
+	return result, firstErr
+}
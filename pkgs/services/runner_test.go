@@ -0,0 +1,396 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"db_optimization_techs/pkgs/models"
+)
+
+// recordingObserver 记录观察者回调的顺序与次数
+type recordingObserver struct {
+	mu     sync.Mutex
+	events []string
+	starts int64
+	done   int64
+	errs   int64
+}
+
+func (o *recordingObserver) PhasePlan(phase string, total int64, duration time.Duration) {
+	o.record(fmt.Sprintf("plan %s %d %s", phase, total, duration))
+}
+
+func (o *recordingObserver) PhaseStart(phase string) { o.record("start " + phase) }
+
+func (o *recordingObserver) PhaseEnd(phase string, result *models.PhaseResult) {
+	o.record(fmt.Sprintf("end %s %d", phase, result.Ops))
+}
+
+func (o *recordingObserver) OpStart(phase string) { atomic.AddInt64(&o.starts, 1) }
+
+func (o *recordingObserver) OpDone(phase string, latency time.Duration, err error) {
+	atomic.AddInt64(&o.done, 1)
+	if err != nil {
+		atomic.AddInt64(&o.errs, 1)
+	}
+}
+
+func (o *recordingObserver) record(event string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, event)
+}
+
+func TestRunPhaseBoundsConcurrency(t *testing.T) {
+	var r runner
+	var inFlight, peak int64
+	result, err := r.runPhase(context.Background(), "op", 200, 8, func(ctx context.Context, index int) ([]string, error) {
+		n := atomic.AddInt64(&inFlight, 1)
+		for {
+			p := atomic.LoadInt64(&peak)
+			if n <= p || atomic.CompareAndSwapInt64(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		atomic.AddInt64(&inFlight, -1)
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Ops != 200 || result.Errors != 0 || result.Concurrency != 8 || result.Incomplete {
+		t.Fatalf("结果不符合预期: %+v", result)
+	}
+	if peak > 8 {
+		t.Fatalf("同时执行的操作数达到 %d，超过并发度 8", peak)
+	}
+	if peak < 2 {
+		t.Fatalf("同时执行的操作数最多只有 %d，worker 没有并发执行", peak)
+	}
+}
+
+func TestRunPhaseDefaultConcurrency(t *testing.T) {
+	var r runner
+	result, err := r.runPhase(context.Background(), "op", 1, 0, func(ctx context.Context, index int) ([]string, error) {
+		return nil, nil
+	})
+	if err != nil || result.Concurrency != defaultConcurrency {
+		t.Fatalf("concurrency <= 0 时应使用默认并发度 %d，实际为 %d（%v）", defaultConcurrency, result.Concurrency, err)
+	}
+}
+
+func TestRunPhaseRunsEveryIndexOnce(t *testing.T) {
+	var r runner
+	seen := make([]int32, 1000)
+	if _, err := r.runPhase(context.Background(), "op", len(seen), 16, func(ctx context.Context, index int) ([]string, error) {
+		atomic.AddInt32(&seen[index], 1)
+		return nil, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for i, n := range seen {
+		if n != 1 {
+			t.Fatalf("第 %d 次操作执行了 %d 次", i, n)
+		}
+	}
+}
+
+func TestRunPhaseAggregatesErrors(t *testing.T) {
+	var r runner
+	errOdd := errors.New("odd")
+	result, err := r.runPhase(context.Background(), "op", 100, 4, func(ctx context.Context, index int) ([]string, error) {
+		switch {
+		case index%10 == 0:
+			return nil, expectRows(0, nil, 1)
+		case index%2 == 1:
+			return nil, errOdd
+		}
+		return nil, nil
+	})
+	if result.Ops != 100 || result.Errors != 60 || result.RowsMismatch != 10 {
+		t.Fatalf("Ops=%d Errors=%d RowsMismatch=%d，预期 100/60/10", result.Ops, result.Errors, result.RowsMismatch)
+	}
+	if !errors.Is(err, errOdd) && !errors.Is(err, errRowsMismatch) {
+		t.Fatalf("返回的错误应为某个失败操作的错误，实际为 %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		if want := i%10 == 0 || i%2 == 1; r.last.failed[i] != want {
+			t.Fatalf("第 %d 次操作的失败记录为 %v，预期 %v", i, r.last.failed[i], want)
+		}
+	}
+}
+
+func TestRunPhaseNotifiesObservers(t *testing.T) {
+	var r runner
+	observer := &recordingObserver{}
+	r.AddObserver(observer)
+	_, err := r.runPhase(context.Background(), "op", 50, 4, func(ctx context.Context, index int) ([]string, error) {
+		if index < 5 {
+			return nil, errors.New("fail")
+		}
+		return nil, nil
+	})
+	if err == nil {
+		t.Fatal("有失败的操作时应返回错误")
+	}
+	want := []string{"plan op 50 0s", "start op", "end op 50"}
+	if fmt.Sprint(observer.events) != fmt.Sprint(want) {
+		t.Fatalf("阶段回调为 %v，预期 %v", observer.events, want)
+	}
+	if observer.starts != 50 || observer.done != 50 || observer.errs != 5 {
+		t.Fatalf("操作回调 starts=%d done=%d errs=%d，预期 50/50/5", observer.starts, observer.done, observer.errs)
+	}
+}
+
+func TestRunPhaseTiming(t *testing.T) {
+	var r runner
+	const latency = 10 * time.Millisecond
+	result, err := r.runPhase(context.Background(), "op", 20, 4, func(ctx context.Context, index int) ([]string, error) {
+		time.Sleep(latency)
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 20 次操作分给 4 个 worker，每个 worker 串行执行 5 次
+	if result.ElapsedMs < 50 {
+		t.Fatalf("阶段耗时 %d ms，少于 5 次串行操作的 50 ms", result.ElapsedMs)
+	}
+	if result.Latency.P50Ms < 10 || result.Latency.MaxMs < result.Latency.P50Ms {
+		t.Fatalf("延迟分布不符合预期: %+v", result.Latency)
+	}
+	if result.OpsPerSec <= 0 || result.OpsPerSec > 4/latency.Seconds() {
+		t.Fatalf("吞吐 %.1f ops/s 超出 4 个 worker 的上限 %.1f", result.OpsPerSec, 4/latency.Seconds())
+	}
+}
+
+func TestRunPhaseSlowestOps(t *testing.T) {
+	r := runner{}
+	r.SetSlowestOps(3)
+	result, _ := r.runPhase(context.Background(), "op", 30, 4, func(ctx context.Context, index int) ([]string, error) {
+		if index >= 27 {
+			time.Sleep(20 * time.Millisecond)
+		}
+		return []string{fmt.Sprint(index)}, nil
+	})
+	if len(result.SlowestOps) != 3 {
+		t.Fatalf("应保留 3 个最慢操作，实际为 %d", len(result.SlowestOps))
+	}
+	for _, op := range result.SlowestOps {
+		if op.Index < 27 || op.Keys[0] != fmt.Sprint(op.Index) {
+			t.Fatalf("最慢操作不符合预期: %+v", op)
+		}
+	}
+}
+
+func TestRunPhaseCancelDrainsInFlightOps(t *testing.T) {
+	var r runner
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var executed sync.Map
+	var cancelledInFlight int64
+	result, err := r.runPhase(ctx, "op", 10000, 8, func(opCtx context.Context, index int) ([]string, error) {
+		executed.Store(index, true)
+		if index == 100 {
+			cancel()
+		}
+		time.Sleep(time.Millisecond)
+		// 中断只停止领取新的操作，进行中的操作不被取消
+		if opCtx.Err() != nil {
+			atomic.AddInt64(&cancelledInFlight, 1)
+		}
+		return nil, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("中断时应返回包装了 context.Canceled 的错误，实际为 %v", err)
+	}
+	if !result.Incomplete || result.Ops <= 100 || result.Ops >= 10000 || result.Errors != 0 {
+		t.Fatalf("中断后的部分结果不符合预期: Ops=%d Errors=%d Incomplete=%v", result.Ops, result.Errors, result.Incomplete)
+	}
+	if cancelledInFlight != 0 {
+		t.Fatalf("%d 个进行中的操作被取消", cancelledInFlight)
+	}
+	// 执行过的操作恰为 [0, Ops)，数据校验依赖这一点
+	for i := 0; i < 10000; i++ {
+		_, ok := executed.Load(i)
+		if ok != (int64(i) < result.Ops) {
+			t.Fatalf("第 %d 次操作执行状态为 %v，Ops=%d", i, ok, result.Ops)
+		}
+	}
+	if r.last.completed != int(result.Ops) {
+		t.Fatalf("记录的完成数 %d 与 Ops %d 不一致", r.last.completed, result.Ops)
+	}
+}
+
+func TestRunPhaseOpTimeout(t *testing.T) {
+	var r runner
+	r.SetOpTimeout(5 * time.Millisecond)
+	result, err := r.runPhase(context.Background(), "op", 20, 4, func(ctx context.Context, index int) ([]string, error) {
+		if index%2 == 0 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return nil, nil
+	})
+	if result.Errors != 10 || result.Incomplete {
+		t.Fatalf("超时的操作应计为失败: Errors=%d Incomplete=%v", result.Errors, result.Incomplete)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("返回的错误应为操作超时，实际为 %v", err)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	throughput := map[int]float64{1: 100, 2: 190, 4: 350, 8: 360, 16: 365}
+	var order []int
+	run := func(ctx context.Context, concurrency int) (*models.PhaseResult, error) {
+		order = append(order, concurrency)
+		return &models.PhaseResult{Concurrency: concurrency, OpsPerSec: throughput[concurrency]}, nil
+	}
+
+	sweep, err := Sweep(context.Background(), "get", []int{16, 1, 4, 2, 8}, run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(order) != "[1 2 4 8 16]" {
+		t.Fatalf("应按并发度升序执行，实际为 %v", order)
+	}
+	if len(sweep.Points) != 5 || sweep.KneeConcurrency != 4 {
+		t.Fatalf("Points=%d Knee=%d，预期 5/4", len(sweep.Points), sweep.KneeConcurrency)
+	}
+
+	if _, err := Sweep(context.Background(), "get", nil, run); err == nil {
+		t.Fatal("空的并发度列表应返回错误")
+	}
+	if _, err := Sweep(context.Background(), "get", []int{0, 1}, run); err == nil {
+		t.Fatal("并发度为 0 时应返回错误")
+	}
+}
+
+func TestSweepKeepsIncompletePoints(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	run := func(ctx context.Context, concurrency int) (*models.PhaseResult, error) {
+		result := &models.PhaseResult{Concurrency: concurrency, OpsPerSec: float64(concurrency)}
+		switch concurrency {
+		case 2:
+			// 单个并发度超时：保留部分结果并继续
+			result.Incomplete = true
+			return result, nil
+		case 4:
+			cancel()
+			result.Incomplete = true
+			return result, ctx.Err()
+		}
+		return result, nil
+	}
+	sweep, err := Sweep(ctx, "get", []int{1, 2, 4, 8}, run)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ctx 结束时应返回中断错误，实际为 %v", err)
+	}
+	if len(sweep.Points) != 3 || !sweep.Points[1].Incomplete {
+		t.Fatalf("应保留前 3 个并发度的结果（含未完成的），实际为 %d 个", len(sweep.Points))
+	}
+}
+
+func TestFindKnee(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []float64
+		want int
+	}{
+		{"无数据", nil, 0},
+		{"始终增长", []float64{100, 200, 400}, 4},
+		{"第一档后饱和", []float64{100, 105, 106}, 1},
+		{"吞吐下降", []float64{100, 200, 150}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var points []*models.PhaseResult
+			for i, ops := range tt.ops {
+				points = append(points, &models.PhaseResult{Concurrency: 1 << i, OpsPerSec: ops})
+			}
+			if got := findKnee(points); got != tt.want {
+				t.Fatalf("findKnee = %d，预期 %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSweepBatchSizes(t *testing.T) {
+	var sizes []int
+	run := func(ctx context.Context, totalRows, batchSize, concurrency int) (*models.PhaseResult, error) {
+		sizes = append(sizes, batchSize)
+		if totalRows != 1000 || concurrency != 4 {
+			t.Fatalf("totalRows=%d concurrency=%d", totalRows, concurrency)
+		}
+		if batchSize == 500 {
+			return nil, errors.New("too large")
+		}
+		return &models.PhaseResult{BatchSize: batchSize}, nil
+	}
+	sweep, err := SweepBatchSizes(context.Background(), 1000, []int{100, 10, 500, 1}, 4, run)
+	if err == nil {
+		t.Fatal("批大小执行失败时应返回错误")
+	}
+	if fmt.Sprint(sizes) != "[1 10 100 500]" || len(sweep.Points) != 3 {
+		t.Fatalf("执行顺序 %v，保留 %d 个结果", sizes, len(sweep.Points))
+	}
+}
+
+func TestRunSoak(t *testing.T) {
+	var r runner
+	var points []*models.SoakPoint
+	var rows int64
+	result, err := r.runSoak(context.Background(), "soak_create", 120*time.Millisecond, 30*time.Millisecond, 4,
+		func(ctx context.Context, index int) ([]string, error) {
+			time.Sleep(time.Millisecond)
+			if index%3 == 0 {
+				return nil, expectRows(0, nil, 1)
+			}
+			atomic.AddInt64(&rows, 1)
+			return nil, nil
+		},
+		func() int64 { return atomic.LoadInt64(&rows) },
+		func(point *models.SoakPoint) { points = append(points, point) })
+	if !errors.Is(err, errRowsMismatch) {
+		t.Fatalf("返回的错误应为影响行数不符，实际为 %v", err)
+	}
+	if result.Incomplete || result.Ops == 0 || result.Errors != result.RowsMismatch {
+		t.Fatalf("结果不符合预期: Ops=%d Errors=%d RowsMismatch=%d", result.Ops, result.Errors, result.RowsMismatch)
+	}
+	if len(points) < 3 {
+		t.Fatalf("120ms 内每 30ms 一个数据点，只收到 %d 个", len(points))
+	}
+	var ops, errs int64
+	for _, point := range points {
+		ops += point.Ops
+		errs += point.Errors
+	}
+	if ops != result.Ops || errs != result.Errors {
+		t.Fatalf("数据点合计 ops=%d errors=%d，与整体结果 %d/%d 不一致", ops, errs, result.Ops, result.Errors)
+	}
+	if last := points[len(points)-1]; last.RowCount != rows {
+		t.Fatalf("最后一个数据点的行数 %d，预期 %d", last.RowCount, rows)
+	}
+}
+
+func TestRunSoakInterrupted(t *testing.T) {
+	var r runner
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	result, err := r.runSoak(ctx, "soak_create", time.Hour, 10*time.Millisecond, 2,
+		func(ctx context.Context, index int) ([]string, error) {
+			time.Sleep(time.Millisecond)
+			return nil, nil
+		}, func() int64 { return 0 }, nil)
+	if !errors.Is(err, context.DeadlineExceeded) || !result.Incomplete {
+		t.Fatalf("提前结束时应返回未完成的结果与中断原因: Incomplete=%v err=%v", result.Incomplete, err)
+	}
+}
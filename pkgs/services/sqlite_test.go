@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"db_optimization_techs/pkgs/dals"
+	"db_optimization_techs/pkgs/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openSQLite 打开 SQLite 数据库 file 并创建全部压测表，测试结束时关闭连接
+func openSQLite(t *testing.T, file string) *gorm.DB {
+	t.Helper()
+	db, err := dals.InitDB(&models.DatabaseConfig{
+		Type: models.DatabaseSQLite,
+		File: file,
+		Pool: models.PoolConfig{MaxOpenConns: 8, MaxIdleConns: 8},
+	}, logger.Discard)
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	for _, table := range dals.BenchmarkTables {
+		if err := dals.CreateTable(db, table); err != nil {
+			t.Fatalf("CreateTable: %v", err)
+		}
+	}
+	return db
+}
+
+// sqliteFiles 集成测试使用的数据库：内存库（单连接）与临时目录中的 WAL 文件库（多连接并发写）
+func sqliteFiles(t *testing.T) map[string]string {
+	return map[string]string{
+		"memory": ":memory:",
+		"file":   filepath.Join(t.TempDir(), "bench.db"),
+	}
+}
+
+// runSQLitePhases 按命令行的方式通过 Phase 依次执行各阶段并校验写入，与内存 DAL 的测试相同，只是使用真实的 GORM DAL
+func runSQLitePhases(t *testing.T, service Benchmark) {
+	t.Helper()
+	ctx := context.Background()
+	service.SetSeed(1)
+	run := func(name string, w models.WorkloadConfig) *models.PhaseResult {
+		t.Helper()
+		phase, err := service.Phase(name, &w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := phase(ctx, 8)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return result
+	}
+	w := models.WorkloadConfig{PhaseOps: 100, BatchTotalRows: 250, BatchSize: 100, UpsertConflictRate: 0.5, TxKeysPerTx: 5, TxMaxRetries: 3, TxMissingKeyRate: 0.1}
+
+	run("create", w)
+	mustVerify(t, service, 100, 50)
+	run("insert_batch", w)
+	mustVerify(t, service, 250, 50)
+	if rows, err := service.EstimateRows(ctx); err != nil || rows != 350 {
+		t.Fatalf("EstimateRows = %d, %v，预期 350", rows, err)
+	}
+
+	zipf := w
+	zipf.Distribution = "zipf"
+	if result := run("get", zipf); result.Errors != 0 {
+		t.Fatalf("get: Errors=%d", result.Errors)
+	}
+	run("update", w)
+	mustVerify(t, service, 100, 50)
+	// 有放回的分布下同一主键会被写入相同的值，也应影响 1 行，不计入 rows_mismatch
+	uniform := w
+	uniform.Distribution = "uniform"
+	if result := run("update", uniform); result.RowsMismatch != 0 {
+		t.Fatalf("update uniform: RowsMismatch=%d", result.RowsMismatch)
+	}
+
+	run("upsert", w)
+	mustVerify(t, service, 100, 50)
+
+	// 文件库的写事务以 BEGIN IMMEDIATE 串行执行，不应出现需要放弃的锁冲突
+	if result := run("tx_rmw", w); result.Counters["commits"] != result.Ops || result.Counters["aborts"] != 0 {
+		t.Fatalf("tx_rmw 计数器 %v，预期 %d 个事务全部提交", result.Counters, result.Ops)
+	}
+	if v, err := service.Verify(ctx, 50); err != nil || !v.Passed {
+		t.Fatalf("tx_rmw 校验: %v %v", v, err)
+	}
+
+	run("delete", w)
+	mustVerify(t, service, 100, 0)
+}
+
+func TestSQLiteTest100mService(t *testing.T) {
+	for name, file := range sqliteFiles(t) {
+		t.Run(name, func(t *testing.T) {
+			runSQLitePhases(t, NewTest100mService(dals.NewTest100mDAL(openSQLite(t, file))))
+		})
+	}
+}
+
+func TestSQLiteTest100mCrc32Service(t *testing.T) {
+	for name, file := range sqliteFiles(t) {
+		t.Run(name, func(t *testing.T) {
+			runSQLitePhases(t, NewTest100mCrc32Service(dals.NewTest100mCrc32DAL(openSQLite(t, file))))
+		})
+	}
+}
+
+// lockingStore 第一次读改写之前，由另一个连接以 BEGIN IMMEDIATE 持有写锁 hold 时长，使之后的事务遇到 SQLITE_BUSY
+type lockingStore struct {
+	dals.Test100mStore
+	holder *gorm.DB
+	hold   time.Duration
+	once   sync.Once
+	err    error
+}
+
+func (s *lockingStore) ReadModifyWrite(ctx context.Context, uuids []string, opts *sql.TxOptions, modify func(record *models.Test100mTable)) (int64, error) {
+	s.once.Do(func() {
+		tx := s.holder.Begin()
+		if s.err = tx.Error; s.err != nil {
+			return
+		}
+		time.AfterFunc(s.hold, func() { tx.Commit() })
+	})
+	if s.err != nil {
+		return 0, s.err
+	}
+	return s.Test100mStore.ReadModifyWrite(ctx, uuids, opts, modify)
+}
+
+func TestSQLiteTxRetriesBusy(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "busy.db")
+	holder := openSQLite(t, file)
+	// 压测连接不等待写锁（busy_timeout 为 0），其他连接持有写锁时 BEGIN IMMEDIATE 立即返回 SQLITE_BUSY
+	db, err := gorm.Open(sqlite.Open(file+"?_pragma=busy_timeout(0)&_pragma=journal_mode(WAL)&_txlock=immediate"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	store := &lockingStore{Test100mStore: dals.NewTest100mDAL(db), holder: holder, hold: 50 * time.Millisecond}
+	service := NewTest100mService(store)
+	service.SetSeed(1)
+	result, err := service.TxReadModifyWrite(context.Background(), 100, 5, "", 1_000_000, 0, 8)
+	if err != nil || result.Errors != 0 {
+		t.Fatalf("SQLITE_BUSY 应重试而不是失败: Errors=%d err=%v", result.Errors, err)
+	}
+	c := result.Counters
+	if c["sqlite_busy"] == 0 || c["retries"] == 0 || c["aborts"] != 0 || c["commits"] != result.Ops {
+		t.Fatalf("计数器 %v，预期遇到 SQLITE_BUSY 后重试并全部提交 %d 个事务", c, result.Ops)
+	}
+	if v, err := service.Verify(context.Background(), 50); err != nil || !v.Passed {
+		t.Fatalf("tx_rmw 校验: %v %v", v, err)
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"db_optimization_techs/pkgs/dals"
+)
+
+func TestTest100mCrc32ServicePhases(t *testing.T) {
+	ctx := context.Background()
+	dal := dals.NewMemoryTest100mCrc32DAL(dals.MemoryOptions{})
+	service := NewTest100mCrc32Service(dal)
+	service.SetSeed(1)
+
+	if _, err := service.Create(ctx, 200, 8); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	mustVerify(t, service, 200, 50)
+
+	if _, err := service.InsertBatch(ctx, 250, 100, 2); err != nil {
+		t.Fatalf("InsertBatch: %v", err)
+	}
+	mustVerify(t, service, 250, 50)
+
+	result, err := service.Get(ctx, 100, "", 4)
+	if err != nil || result.Errors != 0 {
+		t.Fatalf("Get: Errors=%d err=%v", result.Errors, err)
+	}
+
+	if _, err := service.Update(ctx, 100, "shuffled", 4); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	mustVerify(t, service, 100, 50)
+
+	if _, err := service.Upsert(ctx, 100, 0.3, 4); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	mustVerify(t, service, 100, 50)
+
+	if _, err := service.TxReadModifyWrite(ctx, 100, 5, "", 3, 0.1, 4); err != nil {
+		t.Fatalf("TxReadModifyWrite: %v", err)
+	}
+	if v, err := service.Verify(ctx, 50); err != nil || !v.Passed {
+		t.Fatalf("tx_rmw 校验: %v %v", v, err)
+	}
+
+	before := dal.Len()
+	if _, err := service.Delete(ctx, 100, 4); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if dal.Len() != before {
+		t.Fatalf("Delete 后行数 %d，预期 %d", dal.Len(), before)
+	}
+	mustVerify(t, service, 100, 0)
+}
+
+func TestTest100mCrc32ServiceInjectedErrors(t *testing.T) {
+	dal := dals.NewMemoryTest100mCrc32DAL(dals.MemoryOptions{ErrorRate: 0.1, Seed: 5, FailOps: []string{"InsertBatch"}})
+	service := NewTest100mCrc32Service(dal)
+	service.SetSeed(1)
+
+	result, err := service.InsertBatch(context.Background(), 5000, 50, 8)
+	if err == nil || result.Errors == 0 || result.Errors != dal.Injected() {
+		t.Fatalf("Errors=%d Injected=%d err=%v", result.Errors, dal.Injected(), err)
+	}
+	// 失败的批整批未写入
+	if dal.Len() != 5000-50*result.Errors {
+		t.Fatalf("行数 %d，预期 %d", dal.Len(), 5000-50*result.Errors)
+	}
+	mustVerify(t, service, 5000-50*result.Errors, 50)
+}
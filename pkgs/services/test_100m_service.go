@@ -1,287 +1,487 @@
 package services
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"math/rand"
-	"sync"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"db_optimization_techs/pkgs/dals"
 	"db_optimization_techs/pkgs/models"
-
-	"github.com/google/uuid"
 )
 
 // Test100mService 服务层，用于测试 Test100mDAL 的性能
 type Test100mService struct {
-	dal *dals.Test100mDAL
+	runner
+	dal dals.Test100mStore
 }
 
 // NewTest100mService 创建 Test100mService 实例
-func NewTest100mService(dal *dals.Test100mDAL) *Test100mService {
+func NewTest100mService(dal dals.Test100mStore) *Test100mService {
 	return &Test100mService{dal: dal}
 }
 
-// InsertBatch10000 批量插入 10000 条：并行 100 批，每批在 Service 内生成 100 条并调用 DAL.InsertBatch100，返回总耗时（毫秒）
-func (s *Test100mService) InsertBatch10000() (int64, error) {
-	start := time.Now()
-	const batchSize = 100
-	const loopCount = 100
-	const maxConcurrency = 30 // 有界并发，避免打满 DB 连接池
-	sem := make(chan struct{}, maxConcurrency)
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	var firstErr error
-	for batch := 0; batch < loopCount; batch++ {
-		wg.Add(1)
-		sem <- struct{}{}
-		go func(batch int) {
-			defer wg.Done()
-			defer func() { <-sem }()
-			records := make([]*models.Test100mTable, 0, batchSize)
-			for i := 0; i < batchSize; i++ {
-				globalIdx := batch*batchSize + i
-				records = append(records, &models.Test100mTable{
-					Uuid:     uuid.New().String(),
-					Name:     fmt.Sprintf("Name_%d", globalIdx),
-					Email:    fmt.Sprintf("email_%d@test.com", globalIdx),
-					Nickname: fmt.Sprintf("Nickname_%d", globalIdx),
-				})
-			}
-			if err := s.dal.InsertBatch100(records); err != nil {
-				mu.Lock()
-				if firstErr == nil {
-					firstErr = err
-				}
-				mu.Unlock()
-			}
-		}(batch)
-	}
-	wg.Wait()
-	if firstErr != nil {
-		return time.Since(start).Milliseconds(), firstErr
-	}
-	return time.Since(start).Milliseconds(), nil
-}
-
-// Create 循环 1 万次创建记录，返回总耗时（毫秒）
-func (s *Test100mService) Create() (int64, error) {
-	start := time.Now()
-
-	const maxConcurrency = 80
-	sem := make(chan struct{}, maxConcurrency)
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	var errors []error
-
-	for i := 0; i < 10000; i++ {
-		wg.Add(1)
-		sem <- struct{}{}
-
-		go func(index int) {
-			defer wg.Done()
-			defer func() { <-sem }()
-
-			id := uuid.New().String()
-			record := &models.Test100mTable{
-				Uuid:     id,
-				Name:     fmt.Sprintf("Name_%d", index),
-				Email:    fmt.Sprintf("email_%d@test.com", index),
-				Nickname: fmt.Sprintf("Nickname_%d", index),
-			}
-
-			if err := s.dal.Create(record); err != nil {
-				mu.Lock()
-				errors = append(errors, err)
-				mu.Unlock()
-			}
-		}(i)
-	}
-
-	wg.Wait()
-
-	if len(errors) > 0 {
-		return 0, fmt.Errorf("创建完成，但有 %d 个失败: %v", len(errors), errors[0])
+// Phase 按名称返回可重复执行的压测阶段，供并发度扫描等场景使用
+// 支持 create、get、update、upsert、tx_rmw、delete、insert_batch
+func (s *Test100mService) Phase(name string, w *models.WorkloadConfig) (PhaseFunc, error) {
+	switch name {
+	case "create":
+		return func(ctx context.Context, concurrency int) (*models.PhaseResult, error) {
+			return s.Create(ctx, w.PhaseOps, concurrency)
+		}, nil
+	case "get":
+		return func(ctx context.Context, concurrency int) (*models.PhaseResult, error) {
+			return s.Get(ctx, w.PhaseOps, w.Distribution, concurrency)
+		}, nil
+	case "update":
+		return func(ctx context.Context, concurrency int) (*models.PhaseResult, error) {
+			return s.Update(ctx, w.PhaseOps, w.Distribution, concurrency)
+		}, nil
+	case "upsert":
+		return func(ctx context.Context, concurrency int) (*models.PhaseResult, error) {
+			return s.Upsert(ctx, w.PhaseOps, w.UpsertConflictRate, concurrency)
+		}, nil
+	case "tx_rmw":
+		return func(ctx context.Context, concurrency int) (*models.PhaseResult, error) {
+			return s.TxReadModifyWrite(ctx, w.PhaseOps, w.TxKeysPerTx, w.TxIsolation, w.TxMaxRetries, w.TxMissingKeyRate, concurrency)
+		}, nil
+	case "delete":
+		return func(ctx context.Context, concurrency int) (*models.PhaseResult, error) {
+			return s.Delete(ctx, w.PhaseOps, concurrency)
+		}, nil
+	case "insert_batch":
+		return func(ctx context.Context, concurrency int) (*models.PhaseResult, error) {
+			return s.InsertBatch(ctx, w.BatchTotalRows, w.BatchSize, concurrency)
+		}, nil
+	default:
+		return nil, fmt.Errorf("未知的压测阶段: %s", name)
 	}
-
-	elapsed := time.Since(start)
-	return elapsed.Milliseconds(), nil
 }
 
-// Get 先创建 1 万条测试数据，然后随机查询 1 万次，返回总耗时（毫秒）
-func (s *Test100mService) Get() (int64, error) {
-	// 准备阶段：创建 10000 条记录（不计时）
-	uuids := make([]string, 0, 10000)
-	for i := 0; i < 10000; i++ {
-		id := uuid.New().String()
+// prepare 创建 n 条测试数据（不计时），返回其 UUID 列表；prefix 用于区分各阶段的数据
+func (s *Test100mService) prepare(ctx context.Context, prefix string, n int) ([]string, error) {
+	keys := s.stream("prepare_" + prefix)
+	uuids := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("准备测试数据时中断，已创建 %d 条: %w", i, err)
+		}
+		// 主键为 UUID v4 格式，由种子确定
+		id := keys.uuid(i)
 		record := &models.Test100mTable{
 			Uuid:     id,
-			Name:     fmt.Sprintf("TestName_%d", i),
-			Email:    fmt.Sprintf("test_%d@test.com", i),
-			Nickname: fmt.Sprintf("TestNickname_%d", i),
+			Name:     fmt.Sprintf("%sName_%d", prefix, i),
+			Email:    fmt.Sprintf("%s_%d@test.com", strings.ToLower(prefix), i),
+			Nickname: fmt.Sprintf("%sNickname_%d", prefix, i),
 		}
 
-		if err := s.dal.Create(record); err != nil {
-			return 0, fmt.Errorf("创建测试数据失败: %w", err)
+		opCtx, cancel := s.opContext(ctx)
+		err := s.dal.Create(opCtx, record)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 次创建测试数据失败: %w", i+1, err)
 		}
 		uuids = append(uuids, id)
 	}
+	return uuids, nil
+}
 
-	// 随机打乱 UUID 列片
-	rand.Shuffle(len(uuids), func(i, j int) {
-		uuids[i], uuids[j] = uuids[j], uuids[i]
-	})
-
-	// 测试阶段：随机查询 10000 次（计时）
-	start := time.Now()
+// InsertBatch10000 批量插入 10000 条：共 100 批，每批 100 条对应一条 INSERT，返回阶段结果
+// concurrency <= 0 时默认 30 个并发，避免打满 DB 连接池
+func (s *Test100mService) InsertBatch10000(ctx context.Context, concurrency int) (*models.PhaseResult, error) {
+	if concurrency <= 0 {
+		concurrency = 30 // 有界并发，避免打满 DB 连接池
+	}
+	return s.InsertBatch(ctx, 10000, 100, concurrency)
+}
 
-	const maxConcurrency = 80
-	sem := make(chan struct{}, maxConcurrency)
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	var errors []error
+// InsertBatch 以 batchSize 行一条 INSERT 的方式共插入 totalRows 行，返回阶段结果
+// 单次操作延迟为一条批量 INSERT 的耗时，RowsPerSec 为按行计算的吞吐
+func (s *Test100mService) InsertBatch(ctx context.Context, totalRows, batchSize, concurrency int) (*models.PhaseResult, error) {
+	return s.insertBatch(ctx, "insert_batch", totalRows, batchSize, concurrency)
+}
 
-	for _, uuid := range uuids {
-		wg.Add(1)
-		sem <- struct{}{}
+// Preload 与 InsertBatch 相同，但使用独立的数据流，预填充的主键不会与之后 insert_batch 阶段的主键重复
+func (s *Test100mService) Preload(ctx context.Context, totalRows, batchSize, concurrency int) (*models.PhaseResult, error) {
+	return s.insertBatch(ctx, "preload", totalRows, batchSize, concurrency)
+}
 
-		go func(u string) {
-			defer wg.Done()
-			defer func() { <-sem }()
+// insertBatch 以阶段名 name 对应的数据流批量插入 totalRows 行
+func (s *Test100mService) insertBatch(ctx context.Context, name string, totalRows, batchSize, concurrency int) (*models.PhaseResult, error) {
+	if totalRows <= 0 || batchSize <= 0 {
+		return nil, fmt.Errorf("总行数与批大小必须大于 0: totalRows=%d, batchSize=%d", totalRows, batchSize)
+	}
 
-			_, err := s.dal.GetByUUID(u)
-			if err != nil {
-				mu.Lock()
-				errors = append(errors, err)
-				mu.Unlock()
+	batches := (totalRows + batchSize - 1) / batchSize
+	keys := s.stream(name)
+	result, err := s.runPhase(ctx, name, batches, concurrency, func(ctx context.Context, batch int) ([]string, error) {
+		size := batchSize
+		if remaining := totalRows - batch*batchSize; remaining < size {
+			size = remaining
+		}
+		records := make([]*models.Test100mTable, 0, size)
+		for i := 0; i < size; i++ {
+			globalIdx := batch*batchSize + i
+			records = append(records, &models.Test100mTable{
+				Uuid:     keys.uuid(globalIdx),
+				Name:     fmt.Sprintf("Name_%d", globalIdx),
+				Email:    fmt.Sprintf("email_%d@test.com", globalIdx),
+				Nickname: fmt.Sprintf("Nickname_%d", globalIdx),
+			})
+		}
+		// 一批的主键数量较多，不逐个记录
+		return nil, s.dal.InsertBatch(ctx, records)
+	})
+	result.BatchSize = batchSize
+	result.RowsPerSec = result.OpsPerSec * float64(totalRows) / float64(batches)
+	s.expect(&phaseCheck{
+		keys: func(batch int) []string {
+			var batchKeys []string
+			for i := batch * batchSize; i < min((batch+1)*batchSize, totalRows); i++ {
+				batchKeys = append(batchKeys, keys.uuid(i))
 			}
-		}(uuid)
+			return batchKeys
+		},
+		want: func(batch, k int) rowValues { return generatedValues("", batch*batchSize+k) },
+	})
+	if result.Incomplete {
+		return result, err
 	}
+	if result.Errors > 0 {
+		return result, fmt.Errorf("批量插入完成，但有 %d 批失败: %v", result.Errors, err)
+	}
+	return result, nil
+}
 
-	wg.Wait()
+// Create 以 concurrency 个并发循环 total 次创建记录，返回阶段结果
+func (s *Test100mService) Create(ctx context.Context, total, concurrency int) (*models.PhaseResult, error) {
+	keys := s.stream("create")
+	result, err := s.runPhase(ctx, "create", total, concurrency, func(ctx context.Context, index int) ([]string, error) {
+		record := &models.Test100mTable{
+			Uuid:     keys.uuid(index),
+			Name:     fmt.Sprintf("Name_%d", index),
+			Email:    fmt.Sprintf("email_%d@test.com", index),
+			Nickname: fmt.Sprintf("Nickname_%d", index),
+		}
+		return []string{record.Uuid}, s.dal.Create(ctx, record)
+	})
+	s.expect(&phaseCheck{
+		keys: func(index int) []string { return []string{keys.uuid(index)} },
+		want: func(index, _ int) rowValues { return generatedValues("", index) },
+	})
+	if result.Incomplete {
+		return result, err
+	}
+	if result.Errors > 0 {
+		return result, fmt.Errorf("创建完成，但有 %d 个失败: %v", result.Errors, err)
+	}
+	return result, nil
+}
 
-	if len(errors) > 0 {
-		return 0, fmt.Errorf("查询完成，但有 %d 个失败: %v", len(errors), errors[0])
+// Get 先创建 total 条测试数据，然后按 distribution 查询 total 次，返回阶段结果
+// distribution 为空时为 shuffled，即每条记录以随机顺序各查询一次
+func (s *Test100mService) Get(ctx context.Context, total int, distribution string, concurrency int) (*models.PhaseResult, error) {
+	// 准备阶段：创建 total 条记录（不计时）
+	uuids, err := s.prepare(ctx, "Test", total)
+	if err != nil {
+		return nil, err
+	}
+	pick, err := s.stream("get").picker(distribution, "shuffled", uuids)
+	if err != nil {
+		return nil, err
 	}
 
-	elapsed := time.Since(start)
-	return elapsed.Milliseconds(), nil
+	// 测试阶段：查询 total 次（计时）
+	result, err := s.runPhase(ctx, "get", len(uuids), concurrency, func(ctx context.Context, index int) ([]string, error) {
+		key := pick(index)
+		_, err := s.dal.GetByUUID(ctx, key)
+		return []string{key}, err
+	})
+	if result.Incomplete {
+		return result, err
+	}
+	if result.Errors > 0 {
+		return result, fmt.Errorf("查询完成，但有 %d 个失败: %v", result.Errors, err)
+	}
+	return result, nil
 }
 
-// Update 先创建 1 万条测试数据，然后循环更新 1 万次，返回总耗时（毫秒）
-func (s *Test100mService) Update() (int64, error) {
-	// 准备阶段：创建 10000 条记录（不计时）
-	uuids := make([]string, 0, 10000)
-	for i := 0; i < 10000; i++ {
-		id := uuid.New().String()
-		record := &models.Test100mTable{
-			Uuid:     id,
-			Name:     fmt.Sprintf("OriginalName_%d", i),
-			Email:    fmt.Sprintf("original_%d@test.com", i),
-			Nickname: fmt.Sprintf("OriginalNickname_%d", i),
-		}
+// Update 先创建 total 条测试数据，然后按 distribution 更新 total 次，返回阶段结果
+// distribution 为空时为 sequential，即按插入顺序每条记录各更新一次
+func (s *Test100mService) Update(ctx context.Context, total int, distribution string, concurrency int) (*models.PhaseResult, error) {
+	// 准备阶段：创建 total 条记录（不计时）
+	uuids, err := s.prepare(ctx, "Original", total)
+	if err != nil {
+		return nil, err
+	}
+	pick, err := s.stream("update").picker(distribution, "sequential", uuids)
+	if err != nil {
+		return nil, err
+	}
 
-		if err := s.dal.Create(record); err != nil {
-			return 0, fmt.Errorf("创建测试数据失败: %w", err)
+	// 测试阶段：更新 total 次（计时）
+	result, err := s.runPhase(ctx, "update", len(uuids), concurrency, func(ctx context.Context, index int) ([]string, error) {
+		updateRecord := &models.Test100mTable{
+			Uuid:     pick(index),
+			Name:     fmt.Sprintf("UpdatedName_%d", index),
+			Email:    fmt.Sprintf("updated_%d@test.com", index),
+			Nickname: fmt.Sprintf("UpdatedNickname_%d", index),
 		}
-		uuids = append(uuids, id)
+		affected, err := s.dal.Update(ctx, updateRecord)
+		return []string{updateRecord.Uuid}, expectRows(affected, err, 1)
+	})
+	check := &phaseCheck{keys: func(index int) []string { return []string{pick(index)} }}
+	// sequential、shuffled 下每个主键只更新一次，可以比对写入的值；有放回的分布下最终值取决于执行顺序，只校验存在
+	if distribution == "" || distribution == "sequential" || distribution == "shuffled" {
+		check.want = func(index, _ int) rowValues { return generatedValues("Updated", index) }
 	}
+	s.expect(check)
+	if result.Incomplete {
+		return result, err
+	}
+	if result.Errors > 0 {
+		return result, fmt.Errorf("更新完成，但有 %d 个失败: %v", result.Errors, err)
+	}
+	return result, nil
+}
 
-	// 测试阶段：循环更新 10000 次（计时）
-	start := time.Now()
-
-	const maxConcurrency = 80
-	sem := make(chan struct{}, maxConcurrency)
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	var errors []error
+// Upsert 先按 conflictRate 预先创建部分记录，然后 Upsert total 次，返回阶段结果
+// conflictRate 为已存在主键所占比例，取值 [0, 1]；只统计 Upsert 操作的时间
+func (s *Test100mService) Upsert(ctx context.Context, total int, conflictRate float64, concurrency int) (*models.PhaseResult, error) {
+	if conflictRate < 0 || conflictRate > 1 {
+		return nil, fmt.Errorf("冲突率必须在 [0, 1] 之间: %v", conflictRate)
+	}
 
-	for i, uuid := range uuids {
-		wg.Add(1)
-		sem <- struct{}{}
+	// 准备阶段：创建会发生冲突的记录（不计时）
+	uuids, err := s.prepare(ctx, "Original", int(float64(total)*conflictRate))
+	if err != nil {
+		return nil, err
+	}
+	// 其余主键为新生成的 UUID，Upsert 时走插入分支
+	keys := s.stream("upsert")
+	for len(uuids) < total {
+		uuids = append(uuids, keys.uuid(len(uuids)))
+	}
 
-		go func(index int, u string) {
-			defer wg.Done()
-			defer func() { <-sem }()
+	// 打乱顺序，使冲突与插入交替出现
+	keys.shuffle(uuids)
 
-			updateRecord := &models.Test100mTable{
-				Uuid:     u,
-				Name:     fmt.Sprintf("UpdatedName_%d", index),
-				Email:    fmt.Sprintf("updated_%d@test.com", index),
-				Nickname: fmt.Sprintf("UpdatedNickname_%d", index),
-			}
+	// 测试阶段：Upsert total 次（计时）
+	result, err := s.runPhase(ctx, "upsert", len(uuids), concurrency, func(ctx context.Context, index int) ([]string, error) {
+		record := &models.Test100mTable{
+			Uuid:     uuids[index],
+			Name:     fmt.Sprintf("UpsertName_%d", index),
+			Email:    fmt.Sprintf("upsert_%d@test.com", index),
+			Nickname: fmt.Sprintf("UpsertNickname_%d", index),
+		}
+		return []string{record.Uuid}, s.dal.Upsert(ctx, record)
+	})
+	s.expect(&phaseCheck{
+		keys: func(index int) []string { return []string{uuids[index]} },
+		want: func(index, _ int) rowValues { return generatedValues("Upsert", index) },
+	})
+	if result.Incomplete {
+		return result, err
+	}
+	if result.Errors > 0 {
+		return result, fmt.Errorf("Upsert 完成，但有 %d 个失败: %v", result.Errors, err)
+	}
+	return result, nil
+}
 
-			if err := s.dal.Update(updateRecord); err != nil {
-				mu.Lock()
-				errors = append(errors, err)
-				mu.Unlock()
-			}
-		}(i, uuid)
+// TxReadModifyWrite 先创建 total 条测试数据，然后以事务方式执行读改写，返回阶段结果
+// 每个事务随机选取 keysPerTx 个主键，逐个 SELECT ... FOR UPDATE 后更新，共 total/keysPerTx 个事务；
+// 其中 missingKeyRate 比例的主键不存在，锁定读后执行插入，用于观察间隙锁行为。
+// 遇到死锁、锁等待超时或 SQLite 锁冲突时整体重试，最多 maxRetries 次，提交、重试、放弃次数记录在结果的 Counters 中；
+// 只统计事务阶段的时间，单次操作延迟为包含重试在内的整个事务耗时
+func (s *Test100mService) TxReadModifyWrite(ctx context.Context, total, keysPerTx int, isolation string, maxRetries int, missingKeyRate float64, concurrency int) (*models.PhaseResult, error) {
+	if keysPerTx <= 0 {
+		return nil, fmt.Errorf("每个事务的主键数必须大于 0: %d", keysPerTx)
 	}
+	if missingKeyRate < 0 || missingKeyRate > 1 {
+		return nil, fmt.Errorf("不存在主键比例必须在 [0, 1] 之间: %v", missingKeyRate)
+	}
+	level, err := dals.ParseIsolationLevel(isolation)
+	if err != nil {
+		return nil, err
+	}
+	opts := &sql.TxOptions{Isolation: level}
 
-	wg.Wait()
+	// 准备阶段：创建 total 条记录（不计时）
+	uuids, err := s.prepare(ctx, "Original", total)
+	if err != nil {
+		return nil, err
+	}
 
-	if len(errors) > 0 {
-		return 0, fmt.Errorf("更新完成，但有 %d 个失败: %v", len(errors), errors[0])
+	// 测试阶段：执行 total/keysPerTx 个事务（计时）
+	counters := &txCounters{}
+	stream := s.stream("tx_rmw")
+	// 第 index 个事务访问的主键，随机选取、不排序，以便产生锁冲突；校验时按相同方式重新生成
+	txKeys := func(index int) []string {
+		r := stream.rand(index)
+		keys := make([]string, 0, keysPerTx)
+		for k := 0; k < keysPerTx; k++ {
+			if r.Float64() < missingKeyRate {
+				keys = append(keys, stream.uuidFrom(r))
+			} else {
+				keys = append(keys, uuids[r.IntN(len(uuids))])
+			}
+		}
+		return keys
+	}
+	// 放弃的事务没有写入，校验时跳过
+	aborted := make([]atomic.Bool, len(uuids)/keysPerTx)
+	result, err := s.runPhase(ctx, "tx_rmw", len(uuids)/keysPerTx, concurrency, func(ctx context.Context, index int) ([]string, error) {
+		keys := txKeys(index)
+		committed, err := runTxWithRetry(counters, maxRetries, func() error {
+			affected, err := s.dal.ReadModifyWrite(ctx, keys, opts, func(record *models.Test100mTable) {
+				record.Name = fmt.Sprintf("TxName_%d", index)
+				record.Email = fmt.Sprintf("tx_%d@test.com", index)
+				record.Nickname = fmt.Sprintf("TxNickname_%d", index)
+			})
+			return expectRows(affected, err, int64(len(keys)))
+		})
+		if err == nil && !committed {
+			aborted[index].Store(true)
+		}
+		return keys, err
+	})
+	result.Counters = counters.toMap()
+	// 同一主键可能被多个事务写入，最终值取决于执行顺序，只校验提交的事务访问的主键存在
+	s.expect(&phaseCheck{keys: func(index int) []string {
+		if aborted[index].Load() {
+			return nil
+		}
+		return txKeys(index)
+	}})
+	if result.Incomplete {
+		return result, err
 	}
+	if result.Errors > 0 {
+		return result, fmt.Errorf("事务读改写完成，但有 %d 个失败: %v", result.Errors, err)
+	}
+	return result, nil
+}
 
-	elapsed := time.Since(start)
-	return elapsed.Milliseconds(), nil
+// EstimateRows 返回压测表行数的估算值
+func (s *Test100mService) EstimateRows(ctx context.Context) (int64, error) {
+	return s.dal.EstimateRows(ctx)
 }
 
-// Delete 先创建 1 万条记录，然后删除这 1 万条记录，返回总耗时（毫秒）
-// 只统计删除操作的时间，不包含创建记录的时间
-func (s *Test100mService) Delete() (int64, error) {
-	// 准备阶段：创建 10000 条记录（不计时）
-	uuids := make([]string, 0, 10000)
-	for i := 0; i < 10000; i++ {
-		// 使用标准 UUID v4 生成唯一标识
-		id := uuid.New().String()
+// Soak 在 duration 内持续执行 op 指定的负载，每隔 interval 通过 onInterval 输出一个时间序列数据点，返回整体阶段结果
+// op 支持 "create"（持续插入新记录，表随时间增长）与 "mixed"（50% 插入、30% 查询、20% 更新，读写最近插入的记录）
+func (s *Test100mService) Soak(ctx context.Context, op string, duration, interval time.Duration, concurrency int, onInterval func(*models.SoakPoint)) (*models.PhaseResult, error) {
+	baseRows, err := s.dal.EstimateRows(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取表行数失败: %w", err)
+	}
+
+	pool := newKeyPool(soakKeyPoolSize)
+	keys := s.stream("soak_" + op)
+	var inserted int64
+	create := func(ctx context.Context, index int) ([]string, error) {
 		record := &models.Test100mTable{
-			Uuid:     id,
-			Name:     fmt.Sprintf("DeleteName_%d", i),
-			Email:    fmt.Sprintf("delete_%d@test.com", i),
-			Nickname: fmt.Sprintf("DeleteNickname_%d", i),
+			Uuid:     keys.uuid(index),
+			Name:     fmt.Sprintf("SoakName_%d", index),
+			Email:    fmt.Sprintf("soak_%d@test.com", index),
+			Nickname: fmt.Sprintf("SoakNickname_%d", index),
 		}
-
-		// 创建记录（不计时）
-		if err := s.dal.Create(record); err != nil {
-			return 0, fmt.Errorf("第 %d 次创建记录失败: %w", i+1, err)
+		opKeys := []string{record.Uuid}
+		if err := s.dal.Create(ctx, record); err != nil {
+			return opKeys, err
 		}
-		uuids = append(uuids, id)
+		pool.add(record.Uuid)
+		atomic.AddInt64(&inserted, 1)
+		return opKeys, nil
 	}
 
-	// 删除阶段：删除所有记录（只统计这部分时间）
-	start := time.Now()
-
-	const maxConcurrency = 80
-	sem := make(chan struct{}, maxConcurrency)
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	var errors []error
-
-	for _, uuid := range uuids {
-		wg.Add(1)
-		sem <- struct{}{}
-
-		go func(u string) {
-			defer wg.Done()
-			defer func() { <-sem }()
-
-			if err := s.dal.Delete(u); err != nil {
-				mu.Lock()
-				errors = append(errors, err)
-				mu.Unlock()
+	var fn opFunc
+	switch op {
+	case "create":
+		fn = create
+	case "mixed":
+		fn = func(ctx context.Context, index int) ([]string, error) {
+			r := keys.rand(index)
+			key, ok := pool.random(r)
+			switch n := r.IntN(100); {
+			case !ok || n < 50:
+				return create(ctx, index)
+			case n < 80:
+				_, err := s.dal.GetByUUID(ctx, key)
+				return []string{key}, err
+			default:
+				affected, err := s.dal.Update(ctx, &models.Test100mTable{
+					Uuid:     key,
+					Name:     fmt.Sprintf("SoakUpdatedName_%d", index),
+					Email:    fmt.Sprintf("soak_updated_%d@test.com", index),
+					Nickname: fmt.Sprintf("SoakUpdatedNickname_%d", index),
+				})
+				return []string{key}, expectRows(affected, err, 1)
 			}
-		}(uuid)
+		}
+	default:
+		return nil, fmt.Errorf("未知的长时间运行负载: %s", op)
 	}
 
-	wg.Wait()
+	rowCount := func() int64 { return baseRows + atomic.LoadInt64(&inserted) }
+	result, err := s.runSoak(ctx, "soak_"+op, duration, interval, concurrency, fn, rowCount, onInterval)
+	if result != nil && result.Incomplete {
+		return result, err
+	}
+	if err != nil {
+		return result, fmt.Errorf("长时间运行完成，但有 %d 个失败: %v", result.Errors, err)
+	}
+	return result, nil
+}
 
-	if len(errors) > 0 {
-		return 0, fmt.Errorf("删除完成，但有 %d 个失败: %v", len(errors), errors[0])
+// Delete 先创建 total 条记录，然后删除这 total 条记录，返回阶段结果
+// 只统计删除操作的时间，不包含创建记录的时间
+func (s *Test100mService) Delete(ctx context.Context, total, concurrency int) (*models.PhaseResult, error) {
+	// 准备阶段：创建 total 条记录（不计时）
+	uuids, err := s.prepare(ctx, "Delete", total)
+	if err != nil {
+		return nil, err
 	}
 
-	elapsed := time.Since(start)
-	return elapsed.Milliseconds(), nil
+	// 删除阶段：删除所有记录（只统计这部分时间）
+	result, err := s.runPhase(ctx, "delete", len(uuids), concurrency, func(ctx context.Context, index int) ([]string, error) {
+		affected, err := s.dal.Delete(ctx, uuids[index])
+		return []string{uuids[index]}, expectRows(affected, err, 1)
+	})
+	s.expect(&phaseCheck{
+		keys:   func(index int) []string { return []string{uuids[index]} },
+		absent: true,
+	})
+	if result.Incomplete {
+		return result, err
+	}
+	if result.Errors > 0 {
+		return result, fmt.Errorf("删除完成，但有 %d 个失败: %v", result.Errors, err)
+	}
+	return result, nil
+}
+
+// Verify 校验最近一次执行的阶段写入的数据：成功写入的主键均存在（删除阶段为均已删除），
+// 并按种子确定性地抽样至多 sample 行回读比对写入的值；阶段没有可校验的写入（如 get）时返回 nil
+func (s *Test100mService) Verify(ctx context.Context, sample int) (*models.Verification, error) {
+	return s.verify(ctx, s, sample)
+}
+
+// countKeys 实现 rowReader
+func (s *Test100mService) countKeys(ctx context.Context, keys []string) (int64, error) {
+	return s.dal.CountByUUIDs(ctx, keys)
+}
+
+// readRow 实现 rowReader
+func (s *Test100mService) readRow(ctx context.Context, key string) (*rowValues, error) {
+	record, err := s.dal.GetByUUID(ctx, key)
+	if dals.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rowValues{Name: record.Name, Email: record.Email, Nickname: record.Nickname}, nil
 }
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"db_optimization_techs/pkgs/dals"
+	"db_optimization_techs/pkgs/models"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// newMemoryTest100mService 返回使用内存 DAL、种子固定的服务
+func newMemoryTest100mService(opts dals.MemoryOptions) (*Test100mService, *dals.MemoryTest100mDAL) {
+	dal := dals.NewMemoryTest100mDAL(opts)
+	service := NewTest100mService(dal)
+	service.SetSeed(1)
+	return service, dal
+}
+
+// mustVerify 校验最近一次阶段并要求通过
+func mustVerify(t *testing.T, service Benchmark, wantRows int64, wantSampled int) {
+	t.Helper()
+	v, err := service.Verify(context.Background(), 50)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if v == nil {
+		t.Fatal("阶段应有校验结果")
+	}
+	if !v.Passed || v.Rows != wantRows || v.Sampled != wantSampled {
+		t.Fatalf("校验结果 %s（%v），预期 %d 个主键、抽样 %d 行且通过", v, v.Examples, wantRows, wantSampled)
+	}
+}
+
+func TestTest100mServicePhases(t *testing.T) {
+	ctx := context.Background()
+	service, dal := newMemoryTest100mService(dals.MemoryOptions{})
+
+	result, err := service.Create(ctx, 300, 8)
+	if err != nil || result.Ops != 300 || dal.Len() != 300 {
+		t.Fatalf("Create: Ops=%d Len=%d err=%v", result.Ops, dal.Len(), err)
+	}
+	mustVerify(t, service, 300, 50)
+
+	result, err = service.InsertBatch(ctx, 1050, 100, 4)
+	if err != nil || result.Ops != 11 || result.BatchSize != 100 || dal.Len() != 1350 {
+		t.Fatalf("InsertBatch: Ops=%d BatchSize=%d Len=%d err=%v", result.Ops, result.BatchSize, dal.Len(), err)
+	}
+	if result.RowsPerSec <= result.OpsPerSec {
+		t.Fatalf("行吞吐 %.1f 应高于语句吞吐 %.1f", result.RowsPerSec, result.OpsPerSec)
+	}
+	mustVerify(t, service, 1050, 50)
+
+	// get 只读，没有校验
+	if _, err := service.Get(ctx, 200, "zipf", 8); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if v, err := service.Verify(ctx, 50); v != nil || err != nil {
+		t.Fatalf("get 阶段不应有校验结果: %v, %v", v, err)
+	}
+
+	if _, err := service.Update(ctx, 200, "", 8); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	mustVerify(t, service, 200, 50)
+
+	// 有放回的分布下同一主键可能被多次更新，只校验存在
+	result, err = service.Update(ctx, 200, "uniform", 8)
+	if err != nil || result.Ops != 200 {
+		t.Fatalf("Update uniform: Ops=%d err=%v", result.Ops, err)
+	}
+	v, err := service.Verify(ctx, 50)
+	if err != nil || !v.Passed || v.Sampled != 0 || v.Rows > 200 {
+		t.Fatalf("uniform 更新的校验结果 %v, %v", v, err)
+	}
+
+	before := dal.Len()
+	if _, err := service.Upsert(ctx, 200, 0.5, 8); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	// 一半主键预先创建，Upsert 只新增另一半
+	if dal.Len() != before+200 {
+		t.Fatalf("Upsert 后行数 %d，预期 %d", dal.Len(), before+200)
+	}
+	mustVerify(t, service, 200, 50)
+
+	before = dal.Len()
+	if _, err := service.Delete(ctx, 200, 8); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if dal.Len() != before {
+		t.Fatalf("Delete 应删除其准备的全部记录，行数 %d，预期 %d", dal.Len(), before)
+	}
+	mustVerify(t, service, 200, 0)
+}
+
+func TestTest100mServiceSameSeedSameKeys(t *testing.T) {
+	ctx := context.Background()
+	keys := func() []string {
+		service, dal := newMemoryTest100mService(dals.MemoryOptions{})
+		if _, err := service.Create(ctx, 50, 4); err != nil {
+			t.Fatal(err)
+		}
+		stream := newKeyGen(1).stream("create")
+		var uuids []string
+		for i := 0; i < 50; i++ {
+			uuids = append(uuids, stream.uuid(i))
+		}
+		if n, _ := dal.CountByUUIDs(ctx, uuids); n != 50 {
+			t.Fatalf("相同种子下重新生成的 50 个主键中只有 %d 个存在", n)
+		}
+		return uuids
+	}
+	first, second := keys(), keys()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("相同种子第 %d 个主键不同: %s != %s", i, first[i], second[i])
+		}
+	}
+}
+
+// 预填充使用独立的数据流：表已预填充、跳过预填充的目标以相同种子执行 insert_batch 时不会与已有主键冲突
+func TestTest100mServicePreloadStream(t *testing.T) {
+	ctx := context.Background()
+	service, dal := newMemoryTest100mService(dals.MemoryOptions{})
+	if result, err := service.Preload(ctx, 300, 100, 4); err != nil || result.Phase != "preload" {
+		t.Fatalf("Preload: %v %v", result, err)
+	}
+
+	again := NewTest100mService(dal)
+	again.SetSeed(1)
+	if result, err := again.InsertBatch(ctx, 300, 100, 4); err != nil || result.Errors != 0 || dal.Len() != 600 {
+		t.Fatalf("InsertBatch: Len=%d err=%v，不应与预填充的主键重复", dal.Len(), err)
+	}
+}
+
+func TestTest100mServiceInjectedErrors(t *testing.T) {
+	ctx := context.Background()
+	service, dal := newMemoryTest100mService(dals.MemoryOptions{ErrorRate: 0.2, Seed: 7, FailOps: []string{"Create"}})
+
+	result, err := service.Create(ctx, 500, 8)
+	if err == nil {
+		t.Fatal("有失败的操作时 Create 应返回错误")
+	}
+	if result.Errors == 0 || result.Errors != dal.Injected() || result.Ops != 500 {
+		t.Fatalf("Errors=%d Injected=%d Ops=%d", result.Errors, dal.Injected(), result.Ops)
+	}
+	if dal.Len() != 500-result.Errors {
+		t.Fatalf("行数 %d，预期 %d", dal.Len(), 500-result.Errors)
+	}
+	// 校验只针对成功的操作，失败的操作本就没有写入
+	mustVerify(t, service, 500-result.Errors, 50)
+}
+
+// lossyStore 模拟写入静默失效的 DAL：每隔一次 Update 先删掉目标记录（影响 0 行），Create 对 skip 中的主键什么都不做
+type lossyStore struct {
+	*dals.MemoryTest100mDAL
+	updates int64
+	skip    map[string]bool
+}
+
+func (s *lossyStore) Update(ctx context.Context, record *models.Test100mTable) (int64, error) {
+	if atomic.AddInt64(&s.updates, 1)%2 == 0 {
+		s.MemoryTest100mDAL.Delete(ctx, record.Uuid)
+	}
+	return s.MemoryTest100mDAL.Update(ctx, record)
+}
+
+func (s *lossyStore) Create(ctx context.Context, record *models.Test100mTable) error {
+	if s.skip[record.Uuid] {
+		return nil
+	}
+	return s.MemoryTest100mDAL.Create(ctx, record)
+}
+
+func TestTest100mServiceRowsMismatch(t *testing.T) {
+	ctx := context.Background()
+	store := &lossyStore{MemoryTest100mDAL: dals.NewMemoryTest100mDAL(dals.MemoryOptions{})}
+	service := NewTest100mService(store)
+	service.SetSeed(1)
+
+	result, err := service.Update(ctx, 100, "", 4)
+	if err == nil {
+		t.Fatal("影响 0 行的更新应计为失败")
+	}
+	if result.Errors != 50 || result.RowsMismatch != 50 {
+		t.Fatalf("Errors=%d RowsMismatch=%d，预期 50/50", result.Errors, result.RowsMismatch)
+	}
+	// 影响行数不符的操作已计为失败，其余更新确实生效
+	mustVerify(t, service, 50, 50)
+}
+
+func TestTest100mServiceVerifyCatchesSilentWrites(t *testing.T) {
+	ctx := context.Background()
+	store := &lossyStore{MemoryTest100mDAL: dals.NewMemoryTest100mDAL(dals.MemoryOptions{}), skip: make(map[string]bool)}
+	service := NewTest100mService(store)
+	service.SetSeed(1)
+	// 与 Create 阶段使用相同种子的数据流，让其中 3 条写入静默丢失
+	keys := newKeyGen(1).stream("create")
+	for _, i := range []int{3, 30, 60} {
+		store.skip[keys.uuid(i)] = true
+	}
+
+	result, err := service.Create(ctx, 100, 4)
+	if err != nil || result.Errors != 0 {
+		t.Fatalf("静默丢失的写入不会报错: Errors=%d err=%v", result.Errors, err)
+	}
+	v, err := service.Verify(ctx, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Passed || v.Found != 97 || v.Mismatched != 3 {
+		t.Fatalf("校验应发现 3 条丢失的写入: %s", v)
+	}
+}
+
+func TestTest100mServiceTxRetries(t *testing.T) {
+	ctx := context.Background()
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+
+	t.Run("全部死锁时放弃", func(t *testing.T) {
+		service, _ := newMemoryTest100mService(dals.MemoryOptions{ErrorRate: 1, Err: deadlock, FailOps: []string{"ReadModifyWrite"}})
+		result, err := service.TxReadModifyWrite(ctx, 100, 2, "", 2, 0.5, 4)
+		if err != nil || result.Errors != 0 {
+			t.Fatalf("放弃的事务不视为失败: Errors=%d err=%v", result.Errors, err)
+		}
+		want := map[string]int64{"commits": 0, "retries": 100, "aborts": 50, "deadlocks": 150, "lock_wait_timeouts": 0, "sqlite_busy": 0}
+		for name, n := range want {
+			if result.Counters[name] != n {
+				t.Fatalf("计数器 %s = %d，预期 %d（%v）", name, result.Counters[name], n, result.Counters)
+			}
+		}
+		// 放弃的事务没有写入，不存在的主键也未被插入，校验时跳过
+		mustVerify(t, service, 0, 0)
+	})
+
+	t.Run("部分死锁", func(t *testing.T) {
+		service, dal := newMemoryTest100mService(dals.MemoryOptions{ErrorRate: 0.3, Seed: 3, Err: deadlock, FailOps: []string{"ReadModifyWrite"}})
+		result, err := service.TxReadModifyWrite(ctx, 200, 4, "READ COMMITTED", 3, 0.2, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := result.Counters
+		if c["commits"]+c["aborts"] != result.Ops || c["deadlocks"] != dal.Injected() || c["retries"] == 0 {
+			t.Fatalf("计数器不一致: %v，Ops=%d Injected=%d", c, result.Ops, dal.Injected())
+		}
+		v, err := service.Verify(ctx, 50)
+		if err != nil || !v.Passed {
+			t.Fatalf("校验未通过: %v %v", v, err)
+		}
+	})
+
+	t.Run("其他错误直接失败", func(t *testing.T) {
+		service, _ := newMemoryTest100mService(dals.MemoryOptions{ErrorRate: 1, FailOps: []string{"ReadModifyWrite"}})
+		result, err := service.TxReadModifyWrite(ctx, 20, 2, "", 3, 0, 2)
+		if err == nil || result.Errors != 10 || result.Counters["retries"] != 0 {
+			t.Fatalf("Errors=%d retries=%d err=%v", result.Errors, result.Counters["retries"], err)
+		}
+	})
+}
+
+func TestTest100mServiceOpTimeout(t *testing.T) {
+	service, dal := newMemoryTest100mService(dals.MemoryOptions{})
+	ctx := context.Background()
+	if _, err := service.Create(ctx, 20, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	// 不超时的话每次写入要等待一分钟，阶段能结束说明操作确实被超时取消
+	store := &slowStore{MemoryTest100mDAL: dal, delay: time.Minute}
+	slow := NewTest100mService(store)
+	slow.SetSeed(1)
+	slow.SetOpTimeout(5 * time.Millisecond)
+	result, err := slow.Create(ctx, 20, 4)
+	if err == nil || result.Ops != 20 || result.Errors != 20 {
+		t.Fatalf("超时的写入应全部计为失败: Ops=%d Errors=%d err=%v", result.Ops, result.Errors, err)
+	}
+	if n := store.deadlineExceeded.Load(); n != 20 {
+		t.Fatalf("%d 次写入因 context.DeadlineExceeded 结束，预期 20 次", n)
+	}
+	if dal.Len() != 20 {
+		t.Fatalf("超时的写入不应生效，表中有 %d 行", dal.Len())
+	}
+}
+
+// slowStore 每次 Create 都等待 delay，遵守 ctx 的取消，并统计因超时结束的次数
+type slowStore struct {
+	*dals.MemoryTest100mDAL
+	delay            time.Duration
+	deadlineExceeded atomic.Int64
+}
+
+func (s *slowStore) Create(ctx context.Context, record *models.Test100mTable) error {
+	select {
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			s.deadlineExceeded.Add(1)
+		}
+		return ctx.Err()
+	case <-time.After(s.delay):
+	}
+	return s.MemoryTest100mDAL.Create(ctx, record)
+}
+
+func TestTest100mServiceInterrupted(t *testing.T) {
+	service, dal := newMemoryTest100mService(dals.MemoryOptions{Latency: time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	result, err := service.Create(ctx, 100000, 4)
+	if !errors.Is(err, context.DeadlineExceeded) || !result.Incomplete {
+		t.Fatalf("中断时应返回未完成的结果: Incomplete=%v err=%v", result.Incomplete, err)
+	}
+	// 进行中的操作执行完毕，完成的操作都已写入
+	if dal.Len() != result.Ops || result.Errors != 0 {
+		t.Fatalf("行数 %d，Ops %d，Errors %d", dal.Len(), result.Ops, result.Errors)
+	}
+	mustVerify(t, service, result.Ops, 50)
+}
+
+func TestTest100mServiceSoakMixed(t *testing.T) {
+	service, dal := newMemoryTest100mService(dals.MemoryOptions{Latency: 100 * time.Microsecond})
+	var points int
+	result, err := service.Soak(context.Background(), "mixed", 80*time.Millisecond, 20*time.Millisecond, 4,
+		func(*models.SoakPoint) { points++ })
+	if err != nil || result.Errors != 0 || result.Ops == 0 {
+		t.Fatalf("Soak: Ops=%d Errors=%d err=%v", result.Ops, result.Errors, err)
+	}
+	if dal.Len() == 0 || dal.Len() >= result.Ops {
+		t.Fatalf("mixed 负载约一半为插入，行数 %d，操作数 %d", dal.Len(), result.Ops)
+	}
+	if points < 3 {
+		t.Fatalf("只收到 %d 个数据点", points)
+	}
+	if v, _ := service.Verify(context.Background(), 10); v != nil {
+		t.Fatal("长时间运行不做阶段后校验")
+	}
+	if _, err := service.Soak(context.Background(), "unknown", time.Second, time.Second, 1, nil); err == nil {
+		t.Fatal("未知的负载应返回错误")
+	}
+}
+
+func TestTest100mServicePhaseNames(t *testing.T) {
+	service, _ := newMemoryTest100mService(dals.MemoryOptions{})
+	w := models.DefaultConfig().Workload
+	for _, name := range []string{"create", "get", "update", "upsert", "tx_rmw", "delete", "insert_batch"} {
+		if _, err := service.Phase(name, &w); err != nil {
+			t.Fatalf("Phase(%q): %v", name, err)
+		}
+	}
+	if _, err := service.Phase("unknown", &w); err == nil {
+		t.Fatal("未知的阶段应返回错误")
+	}
+}
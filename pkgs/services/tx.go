@@ -0,0 +1,58 @@
+package services
+
+import (
+	"sync/atomic"
+
+	"db_optimization_techs/pkgs/dals"
+)
+
+// txCounters 事务读改写阶段的计数器
+type txCounters struct {
+	commits          int64 // 成功提交的事务数
+	retries          int64 // 因死锁、锁等待超时或 SQLite 锁冲突而整体重试的次数
+	aborts           int64 // 超过最大重试次数后放弃的事务数
+	deadlocks        int64 // 遇到死锁（1213）的次数
+	lockWaitTimeouts int64 // 遇到锁等待超时（1205）的次数
+	sqliteBusy       int64 // 遇到 SQLite 锁冲突（SQLITE_BUSY、SQLITE_LOCKED）的次数
+}
+
+// toMap 转换为阶段结果中的计数器
+func (c *txCounters) toMap() map[string]int64 {
+	return map[string]int64{
+		"commits":            atomic.LoadInt64(&c.commits),
+		"retries":            atomic.LoadInt64(&c.retries),
+		"aborts":             atomic.LoadInt64(&c.aborts),
+		"deadlocks":          atomic.LoadInt64(&c.deadlocks),
+		"lock_wait_timeouts": atomic.LoadInt64(&c.lockWaitTimeouts),
+		"sqlite_busy":        atomic.LoadInt64(&c.sqliteBusy),
+	}
+}
+
+// runTxWithRetry 执行一次事务，遇到死锁、锁等待超时或 SQLite 锁冲突时整体重试，最多重试 maxRetries 次，返回事务是否提交
+// 超过重试次数计为放弃（不视为错误，committed 为 false）；其他错误直接返回
+func runTxWithRetry(counters *txCounters, maxRetries int, fn func() error) (committed bool, err error) {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			atomic.AddInt64(&counters.commits, 1)
+			return true, nil
+		}
+
+		switch {
+		case dals.IsDeadlock(err):
+			atomic.AddInt64(&counters.deadlocks, 1)
+		case dals.IsLockWaitTimeout(err):
+			atomic.AddInt64(&counters.lockWaitTimeouts, 1)
+		case dals.IsSQLiteBusy(err):
+			atomic.AddInt64(&counters.sqliteBusy, 1)
+		default:
+			return false, err
+		}
+
+		if attempt >= maxRetries {
+			atomic.AddInt64(&counters.aborts, 1)
+			return false, nil
+		}
+		atomic.AddInt64(&counters.retries, 1)
+	}
+}
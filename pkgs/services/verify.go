@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"db_optimization_techs/pkgs/models"
+)
+
+// verifyChunkSize 校验行数时每条 IN 查询携带的主键数
+const verifyChunkSize = 1000
+
+// verifyExamples 校验结果中最多保留的不一致示例数
+const verifyExamples = 5
+
+// errRowsMismatch 写操作的影响行数与预期不符，如更新或删除的记录不存在；此类操作计为失败
+var errRowsMismatch = errors.New("影响行数与预期不符")
+
+// expectRows 在写操作成功但影响行数不等于 want 时返回 errRowsMismatch
+func expectRows(affected int64, err error, want int64) error {
+	if err != nil {
+		return err
+	}
+	if affected != want {
+		return fmt.Errorf("%w: 预期 %d 行，实际 %d 行", errRowsMismatch, want, affected)
+	}
+	return nil
+}
+
+// rowValues 校验时比对的列
+type rowValues struct {
+	Name, Email, Nickname string
+}
+
+// generatedValues 返回各阶段以 prefix 与序号 index 生成的写入值，如 ("Updated", 3) 为 UpdatedName_3、updated_3@test.com、UpdatedNickname_3；
+// prefix 为空时为 create、insert_batch 使用的 Name_3、email_3@test.com、Nickname_3
+func generatedValues(prefix string, index int) rowValues {
+	email := "email"
+	if prefix != "" {
+		email = strings.ToLower(prefix)
+	}
+	return rowValues{
+		Name:     fmt.Sprintf("%sName_%d", prefix, index),
+		Email:    fmt.Sprintf("%s_%d@test.com", email, index),
+		Nickname: fmt.Sprintf("%sNickname_%d", prefix, index),
+	}
+}
+
+// rowReader 校验时读取表中数据的方式，由各服务实现
+type rowReader interface {
+	// countKeys 返回 keys 中在表里存在的记录数
+	countKeys(ctx context.Context, keys []string) (int64, error)
+	// readRow 按主键回读一行，记录不存在时返回 nil
+	readRow(ctx context.Context, key string) (*rowValues, error)
+}
+
+// phaseOutcome 一次阶段执行的结果：执行过的操作为 [0, completed)，failed 为其中失败的操作序号
+type phaseOutcome struct {
+	phase     string
+	completed int
+	failed    map[int]bool
+}
+
+// phaseCheck 阶段结束后的校验方式，由服务在阶段执行后设置
+type phaseCheck struct {
+	keys   func(op int) []string     // 第 op 次操作写入或删除的主键
+	want   func(op, k int) rowValues // 第 op 次操作写入 keys(op)[k] 的值，为 nil 时只校验存在（如同一主键被多次写入、结果取决于执行顺序）
+	absent bool                      // 为 true 时校验主键均已不存在（删除阶段）
+}
+
+// expect 设置最近一次执行的阶段的校验方式，须在 runPhase 返回后调用
+func (r *runner) expect(check *phaseCheck) {
+	r.check = check
+}
+
+// verify 校验最近一次执行的阶段：统计成功的操作涉及的主键当前是否存在，再按确定性抽样回读至多 sample 行比对写入的值
+// 阶段没有可校验的写入（如 get、长时间运行）时返回 nil
+func (r *runner) verify(ctx context.Context, reader rowReader, sample int) (*models.Verification, error) {
+	last, check := r.last, r.check
+	if last == nil || check == nil {
+		return nil, nil
+	}
+
+	type row struct{ op, k int }
+	var keys []string
+	var rows []row
+	seen := make(map[string]bool)
+	for op := 0; op < last.completed; op++ {
+		if last.failed[op] {
+			continue
+		}
+		for k, key := range check.keys(op) {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			keys = append(keys, key)
+			rows = append(rows, row{op, k})
+		}
+	}
+
+	v := &models.Verification{Rows: int64(len(keys))}
+	for start := 0; start < len(keys); start += verifyChunkSize {
+		end := min(start+verifyChunkSize, len(keys))
+		found, err := reader.countKeys(ctx, keys[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("校验阶段 %s 的行数失败: %w", last.phase, err)
+		}
+		v.Found += found
+	}
+	countOK := v.Found == v.Rows
+	if check.absent {
+		countOK = v.Found == 0
+		if !countOK {
+			v.Examples = append(v.Examples, fmt.Sprintf("应已删除 %d 行，仍存在 %d 行", v.Rows, v.Found))
+		}
+	} else if !countOK {
+		v.Examples = append(v.Examples, fmt.Sprintf("应存在 %d 行，实际存在 %d 行", v.Rows, v.Found))
+	}
+
+	if check.want != nil && !check.absent && sample > 0 {
+		// 部分洗牌取前 sample 行，相同种子下抽样相同
+		rnd := r.stream("verify_" + last.phase).rand(0)
+		n := min(sample, len(rows))
+		for i := 0; i < n; i++ {
+			j := i + rnd.IntN(len(rows)-i)
+			rows[i], rows[j] = rows[j], rows[i]
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+		for i := 0; i < n; i++ {
+			want := check.want(rows[i].op, rows[i].k)
+			got, err := reader.readRow(ctx, keys[i])
+			if err != nil {
+				return nil, fmt.Errorf("校验阶段 %s 回读主键 %s 失败: %w", last.phase, keys[i], err)
+			}
+			v.Sampled++
+			var problem string
+			switch {
+			case got == nil:
+				problem = fmt.Sprintf("主键 %s 不存在", keys[i])
+			case *got != want:
+				problem = fmt.Sprintf("主键 %s 的值为 %+v，预期 %+v", keys[i], *got, want)
+			default:
+				continue
+			}
+			v.Mismatched++
+			if len(v.Examples) < verifyExamples {
+				v.Examples = append(v.Examples, problem)
+			}
+		}
+	}
+	v.Passed = countOK && v.Mismatched == 0
+	return v, nil
+}
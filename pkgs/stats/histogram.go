@@ -0,0 +1,96 @@
+package stats
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"db_optimization_techs/pkgs/models"
+)
+
+// 直方图桶的划分：从 1 微秒开始按 2^(1/8) 倍递增，共 histogramBuckets 个桶，最后一个桶的上界为 2^(255/8) µs，约 3900s
+const (
+	histogramBuckets = 256
+	bucketsPerDouble = 8
+)
+
+// Histogram 固定内存的对数分桶延迟直方图，用于长时间运行时的延迟统计，可并发写入
+// 分位数按桶上界估算，相对误差不超过约 9%
+type Histogram struct {
+	counts [histogramBuckets]int64
+	count  int64
+	sumUs  int64
+	maxUs  int64
+}
+
+// NewHistogram 创建 Histogram 实例
+func NewHistogram() *Histogram {
+	return &Histogram{}
+}
+
+// Record 记录一次操作耗时
+func (h *Histogram) Record(d time.Duration) {
+	us := d.Microseconds()
+	if us < 1 {
+		us = 1
+	}
+	atomic.AddInt64(&h.counts[bucketIndex(us)], 1)
+	atomic.AddInt64(&h.count, 1)
+	atomic.AddInt64(&h.sumUs, us)
+	for {
+		max := atomic.LoadInt64(&h.maxUs)
+		if us <= max || atomic.CompareAndSwapInt64(&h.maxUs, max, us) {
+			break
+		}
+	}
+}
+
+// Summary 汇总直方图的平均值、分位数与最大值
+func (h *Histogram) Summary() models.LatencySummary {
+	count := atomic.LoadInt64(&h.count)
+	if count == 0 {
+		return models.LatencySummary{}
+	}
+	var counts [histogramBuckets]int64
+	for i := range counts {
+		counts[i] = atomic.LoadInt64(&h.counts[i])
+	}
+	maxUs := atomic.LoadInt64(&h.maxUs)
+
+	percentile := func(p float64) float64 {
+		target := int64(math.Ceil(p / 100 * float64(count)))
+		var seen int64
+		for i, c := range counts {
+			seen += c
+			if seen >= target {
+				return math.Min(bucketUpperUs(i), float64(maxUs)) / 1000
+			}
+		}
+		return float64(maxUs) / 1000
+	}
+
+	return models.LatencySummary{
+		AvgMs: float64(atomic.LoadInt64(&h.sumUs)) / float64(count) / 1000,
+		P50Ms: percentile(50),
+		P90Ms: percentile(90),
+		P99Ms: percentile(99),
+		MaxMs: float64(maxUs) / 1000,
+	}
+}
+
+// bucketIndex 返回耗时（微秒）所在桶的下标
+func bucketIndex(us int64) int {
+	idx := int(math.Ceil(math.Log2(float64(us)) * bucketsPerDouble))
+	if idx < 0 {
+		return 0
+	}
+	if idx >= histogramBuckets {
+		return histogramBuckets - 1
+	}
+	return idx
+}
+
+// bucketUpperUs 返回第 i 个桶的上界（微秒）
+func bucketUpperUs(i int) float64 {
+	return math.Pow(2, float64(i)/bucketsPerDouble)
+}
@@ -0,0 +1,88 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"db_optimization_techs/pkgs/models"
+)
+
+// Recorder 线程安全的延迟记录器，保存每次操作的耗时用于计算分位数
+type Recorder struct {
+	mu      sync.Mutex
+	samples []time.Duration
+}
+
+// NewRecorder 创建 Recorder 实例，capacity 为预估的样本数
+func NewRecorder(capacity int) *Recorder {
+	return &Recorder{samples: make([]time.Duration, 0, capacity)}
+}
+
+// Record 记录一次操作耗时
+func (r *Recorder) Record(d time.Duration) {
+	r.mu.Lock()
+	r.samples = append(r.samples, d)
+	r.mu.Unlock()
+}
+
+// Summary 汇总已记录样本的平均值、分位数与最大值
+func (r *Recorder) Summary() models.LatencySummary {
+	r.mu.Lock()
+	sorted := make([]time.Duration, len(r.samples))
+	copy(sorted, r.samples)
+	r.mu.Unlock()
+
+	return Summarize(sorted)
+}
+
+// Drain 汇总自上次 Drain 以来记录的样本并清空，返回样本数与延迟摘要，用于按时间间隔统计
+func (r *Recorder) Drain() (int, models.LatencySummary) {
+	r.mu.Lock()
+	samples := r.samples
+	r.samples = make([]time.Duration, 0, cap(samples))
+	r.mu.Unlock()
+
+	return len(samples), Summarize(samples)
+}
+
+// Summarize 计算样本的延迟摘要，samples 会被原地排序
+func Summarize(samples []time.Duration) models.LatencySummary {
+	if len(samples) == 0 {
+		return models.LatencySummary{}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	var sum time.Duration
+	for _, d := range samples {
+		sum += d
+	}
+	return models.LatencySummary{
+		AvgMs: toMs(sum / time.Duration(len(samples))),
+		P50Ms: toMs(Percentile(samples, 50)),
+		P90Ms: toMs(Percentile(samples, 90)),
+		P99Ms: toMs(Percentile(samples, 99)),
+		MaxMs: toMs(samples[len(samples)-1]),
+	}
+}
+
+// Percentile 使用最近秩法计算已排序样本的第 p 百分位数
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// toMs 将耗时转换为毫秒，保留微秒精度
+func toMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,83 @@
+package stats
+
+import (
+	"container/heap"
+	"sort"
+	"time"
+
+	"db_optimization_techs/pkgs/models"
+)
+
+// Slowest 保留耗时最长的 n 次操作，内部为以延迟排序的小顶堆，堆顶是当前保留的最快一次
+// 非线程安全，并发场景下每个 worker 各自持有一个实例，结束后通过 Merge 合并
+type Slowest struct {
+	n   int
+	ops slowOpHeap
+}
+
+// NewSlowest 创建保留 n 次操作的 Slowest 实例，n <= 0 时不保留任何操作
+func NewSlowest(n int) *Slowest {
+	return &Slowest{n: n}
+}
+
+// Admits 判断耗时 latency 的操作是否会被保留，用于在构造操作详情前快速过滤
+func (s *Slowest) Admits(latency time.Duration) bool {
+	if s.n <= 0 {
+		return false
+	}
+	return len(s.ops) < s.n || latency > s.ops[0].latency
+}
+
+// Add 记录一次操作，超出容量时淘汰其中最快的一次
+func (s *Slowest) Add(latency time.Duration, op *models.SlowOp) {
+	if !s.Admits(latency) {
+		return
+	}
+	op.LatencyMs = toMs(latency)
+	if len(s.ops) < s.n {
+		heap.Push(&s.ops, slowOpEntry{latency: latency, op: op})
+		return
+	}
+	s.ops[0] = slowOpEntry{latency: latency, op: op}
+	heap.Fix(&s.ops, 0)
+}
+
+// Merge 把 other 中保留的操作合并进来
+func (s *Slowest) Merge(other *Slowest) {
+	for _, entry := range other.ops {
+		s.Add(entry.latency, entry.op)
+	}
+}
+
+// Sorted 返回保留的操作，按延迟从高到低排列
+func (s *Slowest) Sorted() []*models.SlowOp {
+	entries := append(slowOpHeap(nil), s.ops...)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].latency > entries[j].latency })
+	ops := make([]*models.SlowOp, 0, len(entries))
+	for _, entry := range entries {
+		ops = append(ops, entry.op)
+	}
+	return ops
+}
+
+// slowOpEntry 堆中的一个元素
+type slowOpEntry struct {
+	latency time.Duration
+	op      *models.SlowOp
+}
+
+// slowOpHeap 按延迟排序的小顶堆，实现 heap.Interface
+type slowOpHeap []slowOpEntry
+
+func (h slowOpHeap) Len() int           { return len(h) }
+func (h slowOpHeap) Less(i, j int) bool { return h[i].latency < h[j].latency }
+func (h slowOpHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *slowOpHeap) Push(x interface{}) { *h = append(*h, x.(slowOpEntry)) }
+
+func (h *slowOpHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
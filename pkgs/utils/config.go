@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// durationType 配置中的时长字段按 "10s" 形式输出，与配置文件的写法一致
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// MarshalConfig 将配置序列化为缩进格式的 JSON
+// 字段顺序与结构体定义一致，time.Duration 输出为 "1h0m0s" 形式的字符串（encoding/json 会输出纳秒整数），
+// 结果可直接作为配置文件读取
+func MarshalConfig(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeConfigValue(&buf, reflect.ValueOf(v), ""); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func writeConfigValue(buf *bytes.Buffer, v reflect.Value, indent string) error {
+	if v.Type() == durationType {
+		return writeJSON(buf, v.Interface().(time.Duration).String())
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeConfigValue(buf, v.Elem(), indent)
+	case reflect.Struct:
+		buf.WriteString("{")
+		first := true
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			if !first {
+				buf.WriteString(",")
+			}
+			first = false
+			buf.WriteString("\n" + indent + "  ")
+			if err := writeJSON(buf, name); err != nil {
+				return err
+			}
+			buf.WriteString(": ")
+			if err := writeConfigValue(buf, v.Field(i), indent+"  "); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		if !first {
+			buf.WriteString("\n" + indent)
+		}
+		buf.WriteString("}")
+		return nil
+	case reflect.Slice:
+		// 结构体列表每项占多行，其余列表写在同一行，如 [1, 2, 4]
+		if v.Type().Elem().Kind() == reflect.Struct && v.Len() > 0 {
+			buf.WriteString("[")
+			for i := 0; i < v.Len(); i++ {
+				if i > 0 {
+					buf.WriteString(",")
+				}
+				buf.WriteString("\n" + indent + "  ")
+				if err := writeConfigValue(buf, v.Index(i), indent+"  "); err != nil {
+					return err
+				}
+			}
+			buf.WriteString("\n" + indent + "]")
+			return nil
+		}
+		buf.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			if err := writeConfigValue(buf, v.Index(i), indent); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+		return nil
+	default:
+		return writeJSON(buf, v.Interface())
+	}
+}
+
+func writeJSON(buf *bytes.Buffer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
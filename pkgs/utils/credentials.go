@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"db_optimization_techs/pkgs/models"
+)
+
+// ResolvePassword 在配置中没有密码时，依次从以下来源读取数据库密码，取第一个找到的：
+//  1. password_file 指定的文件（整个文件内容，忽略末尾换行）
+//  2. ~/.my.cnf 的 [client] 段中的 password
+//  3. ~/.pgpass（或 PGPASSFILE 指定的文件）中与 host、port、database、user 匹配的行
+//
+// 都没有时保持为空，按无密码连接；password_file 已配置但读取失败时返回错误；SQLite 不需要密码，不读取
+func ResolvePassword(cfg *models.DatabaseConfig) error {
+	if cfg.Password != "" || cfg.Type == models.DatabaseSQLite {
+		return nil
+	}
+	if cfg.PasswordFile != "" {
+		data, err := os.ReadFile(cfg.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("读取密码文件失败: %w", err)
+		}
+		cfg.Password = strings.TrimRight(string(data), "\r\n")
+		return nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	if password, err := myCnfPassword(filepath.Join(home, ".my.cnf")); err != nil {
+		return err
+	} else if password != "" {
+		cfg.Password = password
+		return nil
+	}
+
+	pgpass := os.Getenv("PGPASSFILE")
+	if pgpass == "" {
+		pgpass = filepath.Join(home, ".pgpass")
+	}
+	password, err := pgpassPassword(pgpass, cfg.Host, strconv.Itoa(cfg.Port), cfg.Database, cfg.User)
+	if err != nil {
+		return err
+	}
+	cfg.Password = password
+	return nil
+}
+
+// myCnfPassword 读取 MySQL 选项文件 [client] 段中的 password，文件不存在时返回空
+func myCnfPassword(path string) (string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	defer f.Close()
+
+	var section, password string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || section != "client" || strings.TrimSpace(key) != "password" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		password = value
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	return password, nil
+}
+
+// pgpassPassword 在 .pgpass 格式（hostname:port:database:username:password，"*" 匹配任意值，
+// "\" 转义 ":" 与 "\"）的文件中查找第一条匹配的密码，文件不存在或没有匹配时返回空
+func pgpassPassword(path, host, port, database, user string) (string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	defer f.Close()
+
+	want := []string{host, port, database, user}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := splitPgpass(line)
+		if len(fields) != 5 {
+			continue
+		}
+		matched := true
+		for i, w := range want {
+			if fields[i] != "*" && fields[i] != w {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return fields[4], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	return "", nil
+}
+
+// splitPgpass 按未转义的 ":" 拆分 .pgpass 的一行
+func splitPgpass(line string) []string {
+	var fields []string
+	var field strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line):
+			i++
+			field.WriteByte(line[i])
+		case line[i] == ':':
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteByte(line[i])
+		}
+	}
+	return append(fields, field.String())
+}
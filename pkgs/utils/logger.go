@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"db_optimization_techs/pkgs/models"
+)
+
+// InitLogger 按配置创建结构化日志并设为 slog 默认日志，标准库 log 的输出也会转到该日志
+// 返回的 close 用于在程序退出前关闭日志文件，输出到标准输出时为空操作
+func InitLogger(cfg *models.LogConfig) (close func() error, err error) {
+	var level slog.Level
+	if cfg.Level != "" {
+		if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+			return nil, fmt.Errorf("无效的日志级别 %q: %w", cfg.Level, err)
+		}
+	}
+
+	var out io.Writer
+	close = func() error { return nil }
+	switch cfg.Output {
+	case "", "stdout":
+		out = os.Stdout
+	case "stderr":
+		out = os.Stderr
+	default:
+		if dir := filepath.Dir(cfg.Output); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, fmt.Errorf("创建日志目录失败: %w", err)
+			}
+		}
+		f, err := os.OpenFile(cfg.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("打开日志文件 %s 失败: %w", cfg.Output, err)
+		}
+		out, close = f, f.Close
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(out, opts)
+	case "json":
+		handler = slog.NewJSONHandler(out, opts)
+	default:
+		return nil, fmt.Errorf("不支持的日志格式: %q（可选 text、json）", cfg.Format)
+	}
+	slog.SetDefault(slog.New(handler))
+	return close, nil
+}
+
+// Fatal 以 error 级别记录日志后退出程序，用于命令行入口处无法继续运行的错误
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
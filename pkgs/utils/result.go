@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteJSONFile 将 v 以缩进格式的 JSON 写入 path，自动创建所在目录
+func WriteJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化结果失败: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("创建结果目录失败: %w", err)
+		}
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// JSONLinesWriter 以 JSON Lines 格式逐行追加写入记录，每次写入后立即落盘，适合长时间运行的时间序列输出
+type JSONLinesWriter struct {
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewJSONLinesWriter 创建（或追加打开）path 对应的 JSON Lines 文件，自动创建所在目录
+func NewJSONLinesWriter(path string) (*JSONLinesWriter, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("创建时间序列目录失败: %w", err)
+		}
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("打开时间序列文件失败: %w", err)
+	}
+	return &JSONLinesWriter{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// Write 写入一条记录并同步到磁盘
+func (w *JSONLinesWriter) Write(v interface{}) error {
+	if err := w.enc.Encode(v); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+// Close 关闭文件
+func (w *JSONLinesWriter) Close() error {
+	return w.file.Close()
+}
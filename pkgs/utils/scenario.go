@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"fmt"
+
+	"db_optimization_techs/pkgs/models"
+
+	"github.com/spf13/viper"
+)
+
+// LoadScenario 读取场景文件，格式由扩展名决定（.yaml、.yml、.json），并校验全部字段
+func LoadScenario(path string) (*models.Scenario, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("读取场景文件失败: %w", err)
+	}
+	var scenario models.Scenario
+	if err := v.Unmarshal(&scenario); err != nil {
+		return nil, fmt.Errorf("解析场景文件失败: %w", err)
+	}
+	if err := scenario.Validate(); err != nil {
+		return nil, fmt.Errorf("场景文件 %s 校验失败:\n%w", path, err)
+	}
+	return &scenario, nil
+}
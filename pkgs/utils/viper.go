@@ -1,12 +1,113 @@
 package utils
 
-import "github.com/spf13/viper"
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
 
-func InitViper(confPath string) error {
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
 
-	viper.SetConfigName("config")
-	viper.SetConfigType("json")
-	viper.AddConfigPath(confPath)
+// EnvPrefix 环境变量前缀，配置项 database.password 对应环境变量 DBBENCH_DATABASE_PASSWORD
+const EnvPrefix = "DBBENCH"
+
+// InitViper 读取配置文件 configFile，文件格式由扩展名决定（如 .json）；configFile 为空时不读取文件
+// keys 为全部配置项（见 ConfigKeys），每一项都可以用带 EnvPrefix 前缀的环境变量覆盖，
+// 即使配置文件中没有出现该项
+func InitViper(configFile string, keys []string) error {
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	for _, key := range keys {
+		if err := viper.BindEnv(key); err != nil {
+			return err
+		}
+	}
+
+	if configFile == "" {
+		return nil
+	}
+	viper.SetConfigFile(configFile)
 
 	return viper.ReadInConfig()
 }
+
+// EnvName 返回配置项对应的环境变量名
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// SetOverrides 以 key=value 形式覆盖配置项，优先级高于环境变量与配置文件
+// 列表写作逗号分隔（如 workload.sweep_concurrency=1,2,4），时长写作 "10s"；key 不在 keys 中时报错
+func SetOverrides(overrides []string, keys []string) error {
+	for _, override := range overrides {
+		key, value, ok := strings.Cut(override, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || key == "" {
+			return fmt.Errorf("无效的覆盖项 %q，应写作 key=value", override)
+		}
+		if !slices.Contains(keys, key) {
+			return fmt.Errorf("未知的配置项 %q", key)
+		}
+		viper.Set(key, value)
+	}
+	return nil
+}
+
+// ConfigKeys 按 mapstructure 标签列出配置结构体的全部配置项，嵌套结构体以 "." 连接，如 database.pool.max_open_conns
+func ConfigKeys(v any) []string {
+	return appendConfigKeys(nil, reflect.TypeOf(v), "")
+}
+
+func appendConfigKeys(keys []string, t reflect.Type, prefix string) []string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct {
+			keys = appendConfigKeys(keys, field.Type, prefix+name+".")
+			continue
+		}
+		keys = append(keys, prefix+name)
+	}
+	return keys
+}
+
+// UnmarshalList 将配置项 key 下的列表逐项解析为 T，每一项以 base 为初始值，项中未出现的字段保留 base 的值
+// 配置项不存在时返回 nil；时长与列表的写法与 viper.Unmarshal 相同
+func UnmarshalList[T any](key string, base T) ([]T, error) {
+	raw := viper.Get(key)
+	if raw == nil {
+		return nil, nil
+	}
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: 应为列表", key)
+	}
+	list := make([]T, 0, len(items))
+	for i, item := range items {
+		v := base
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook: mapstructure.ComposeDecodeHookFunc(
+				mapstructure.StringToTimeDurationHookFunc(),
+				mapstructure.StringToSliceHookFunc(","),
+			),
+			WeaklyTypedInput: true,
+			Result:           &v,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := decoder.Decode(item); err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", key, i, err)
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}